@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/health"
+	"github.com/ihippik/template-service/metrics"
+)
+
+// levelPayload is the /admin/loglevel request and response body.
+type levelPayload struct {
+	Level string `json:"level" example:"info"`
+}
+
+// buildInfo is the /admin/version response.
+type buildInfo struct {
+	Version   string            `json:"version"`
+	GoVersion string            `json:"goVersion"`
+	Settings  map[string]string `json:"settings,omitempty"`
+}
+
+// adminHandler serves operational endpoints on the admin address, apart from the public API.
+// Probes and metrics are open, the admin and pprof endpoints require the bearer token
+// and are not served when it isn't set.
+func adminHandler(checker *health.Checker, level zap.AtomicLevel, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", checker.Live)
+	mux.HandleFunc("/readyz", checker.Ready)
+
+	if token == "" {
+		return mux
+	}
+
+	private := http.NewServeMux()
+
+	private.HandleFunc("/admin/loglevel", logLevel(level))
+	private.HandleFunc("/admin/version", version)
+
+	private.HandleFunc("/debug/pprof/", pprof.Index)
+	private.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	private.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	private.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	private.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.Handle("/admin/", requireToken(token, private))
+	mux.Handle("/debug/pprof/", requireToken(token, private))
+
+	return mux
+}
+
+// requireToken rejects requests without the bearer token with 401.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(credentials), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logLevel GET returns the current log level, PUT with the same body changes it without a restart.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Description get the log level or change it with a PUT of the same body, served on the admin address without the /v1 prefix
+// @Summary log level
+// @Security AdminToken
+// @Success 200 {object} levelPayload
+// @Failure 400 {object} map[string]string
+// @Failure 401 {string} string
+// @Router /admin/loglevel [GET]
+// @Router /admin/loglevel [PUT]
+func logLevel(level zap.AtomicLevel) http.HandlerFunc {
+	return level.ServeHTTP
+}
+
+// version reports the git version of the binary, the Go version and the build settings (vcs, flags).
+// @Tags Admin
+// @Produce json
+// @Description version of the binary, served on the admin address without the /v1 prefix
+// @Summary build info
+// @Security AdminToken
+// @Success 200 {object} buildInfo
+// @Failure 401 {string} string
+// @Router /admin/version [GET]
+func version(w http.ResponseWriter, _ *http.Request) {
+	info := buildInfo{Version: gitVersion, GoVersion: runtime.Version()}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Settings = make(map[string]string, len(bi.Settings))
+
+		for _, s := range bi.Settings {
+			info.Settings[s.Key] = s.Value
+		}
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
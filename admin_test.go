@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ihippik/template-service/health"
+)
+
+func TestAdminHandler(t *testing.T) {
+	checker := health.NewChecker(zap.NewNop(), time.Second)
+
+	tests := []struct {
+		name         string
+		token        string
+		path         string
+		auth         string
+		wantHTTPCode int
+	}{
+		{
+			name:         "probe is open",
+			token:        "secret",
+			path:         "/healthz",
+			wantHTTPCode: http.StatusOK,
+		},
+		{
+			name:         "no token",
+			token:        "secret",
+			path:         "/admin/version",
+			wantHTTPCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "wrong token",
+			token:        "secret",
+			path:         "/debug/pprof/",
+			auth:         "Bearer other",
+			wantHTTPCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "token",
+			token:        "secret",
+			path:         "/debug/pprof/",
+			auth:         "Bearer secret",
+			wantHTTPCode: http.StatusOK,
+		},
+		{
+			name:         "disabled without token",
+			path:         "/admin/version",
+			auth:         "Bearer ",
+			wantHTTPCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+
+			w := httptest.NewRecorder()
+
+			adminHandler(checker, zap.NewAtomicLevel(), tt.token).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantHTTPCode, w.Code)
+		})
+	}
+}
+
+func TestAdminHandler_version(t *testing.T) {
+	handler := adminHandler(health.NewChecker(zap.NewNop(), time.Second), zap.NewAtomicLevel(), "secret")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/version", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	var info buildInfo
+
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+	assert.Equal(t, gitVersion, info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+func TestAdminHandler_loglevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	handler := adminHandler(health.NewChecker(zap.NewNop(), time.Second), level, "secret")
+
+	do := func(method, body string) (int, string) {
+		req := httptest.NewRequest(method, "/admin/loglevel", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		data, err := io.ReadAll(w.Result().Body)
+		require.NoError(t, err)
+
+		return w.Code, strings.TrimSpace(string(data))
+	}
+
+	code, body := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"level":"info"}`, body)
+
+	code, body = do(http.MethodPut, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"level":"debug"}`, body)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	code, _ = do(http.MethodPut, `{"level":"loud"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
@@ -0,0 +1,199 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+type service interface {
+	GetKey(ctx context.Context, id uuid.UUID) (*Key, error)
+	ListKeys(ctx context.Context) ([]*Key, error)
+	CreateKey(ctx context.Context, dto DTO) (*Issued, error)
+	RevokeKey(ctx context.Context, id uuid.UUID) error
+}
+
+type Endpoint struct {
+	logger *zap.Logger
+	svc    service
+}
+
+func NewEndpoint(logger *zap.Logger, svc service) *Endpoint {
+	return &Endpoint{logger: logger, svc: svc}
+}
+
+type response struct {
+	Data []*Key `json:"data,omitempty"`
+}
+
+type issuedResponse struct {
+	Data []*Issued `json:"data,omitempty"`
+}
+
+// ListKeys http list api keys handler.
+// @Title List
+// @Tags ApiKey
+// @Accept json
+// @Produce json,application/problem+json
+// @Description list api keys, secrets are never returned
+// @Summary fetch api keys
+// @Success 200 {object} response
+// @Failure 500 {object} apperr.Problem
+// @Router /v1/api-keys [GET]
+func (e *Endpoint) ListKeys(w http.ResponseWriter, r *http.Request) {
+	var resp response
+
+	w.Header().Set("Content-Type", "application/json")
+
+	models, err := e.svc.ListKeys(r.Context())
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = models
+	e.writeResp(w, r, resp)
+}
+
+// CreateKey http issue api key handler.
+// @Title Create
+// @Tags ApiKey
+// @Accept json
+// @Produce json,application/problem+json
+// @Description issue api key, the token is returned only in this response
+// @Summary issue api key
+// @Success 201 {object} issuedResponse
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 403 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 500 {object} apperr.Problem
+// @Param model body DTO true "New model"
+// @Router /v1/api-keys [POST]
+func (e *Endpoint) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		dto  DTO
+		resp issuedResponse
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		e.logger.Warn("decode api key data", zap.Error(err))
+		e.writeErr(w, r, newDecodeErr(InvalidKeyData, &dto, err))
+
+		return
+	}
+
+	model, err := e.svc.CreateKey(r.Context(), dto)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = append(resp.Data, model)
+
+	w.WriteHeader(http.StatusCreated)
+	e.writeResp(w, r, resp)
+}
+
+// GetKey http get api key handler.
+// @Title Get
+// @Tags ApiKey
+// @Accept json
+// @Produce json,application/problem+json
+// @Description get api key by id
+// @Summary get api key
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param id path string true "API key ID"
+// @Router /v1/api-keys/{id} [GET]
+func (e *Endpoint) GetKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := e.parseKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	model, err := e.svc.GetKey(r.Context(), id)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	var resp response
+
+	resp.Data = append(resp.Data, model)
+
+	e.writeResp(w, r, resp)
+}
+
+// RevokeKey http revoke api key handler.
+// @Title Revoke
+// @Tags ApiKey
+// @Accept json
+// @Produce json,application/problem+json
+// @Description revoke api key by id
+// @Summary revoke api key
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param id path string true "API key ID"
+// @Router /v1/api-keys/{id} [DELETE]
+func (e *Endpoint) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := e.parseKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.svc.RevokeKey(r.Context(), id); err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	var resp response
+
+	resp.Data = []*Key{}
+
+	e.writeResp(w, r, resp)
+}
+
+func (e *Endpoint) parseKeyID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		e.logger.Warn("could not parse api key id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidKeyID, err.Error()))
+
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (e *Endpoint) writeResp(w http.ResponseWriter, r *http.Request, uData any) {
+	data, err := json.Marshal(uData)
+	if err != nil {
+		e.writeErr(w, r, newInternalServer(InternalServerError, err.Error()))
+		e.logger.Warn("marshal data", zap.Error(err))
+		return
+	}
+
+	if _, err = w.Write(data); err != nil {
+		e.logger.Error("write error", zap.Error(err))
+	}
+}
+
+func (e *Endpoint) writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	apperr.Write(w, r, e.logger, err)
+}
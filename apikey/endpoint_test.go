@@ -0,0 +1,173 @@
+package apikey
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// serve routes the request through the pattern main registers the handler with.
+func serve(pattern string, h http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, h)
+	mux.ServeHTTP(w, r)
+}
+
+func TestEndpoint_CreateKey(t *testing.T) {
+	svc := new(MockServer)
+
+	setCreate := func(dto DTO, issued *Issued, err error) {
+		svc.On("CreateKey", mock.Anything, dto).Return(issued, err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		body         []byte
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "success",
+			body: []byte(`{"name":"batch","scopes":["users:read"]}`),
+			setup: func() {
+				setCreate(
+					DTO{Name: "batch", Scopes: []string{"users:read"}},
+					&Issued{
+						Key: &Key{
+							ID:        keyID,
+							Name:      "batch",
+							Scopes:    pq.StringArray{"users:read"},
+							Hash:      []byte("hash"),
+							Salt:      []byte("salt"),
+							CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+						},
+						Token: "tsk_token",
+					},
+					nil,
+				)
+			},
+			wantHTTPCode: http.StatusCreated,
+			want:         []byte(`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","name":"batch","scopes":["users:read"],"expiresAt":null,"lastUsedAt":null,"revokedAt":null,"createdAt":"2022-11-17T20:00:00Z","token":"tsk_token"}]}`),
+		},
+		{
+			name: "validation error",
+			body: []byte(`{"scopes":["users:read"]}`),
+			setup: func() {
+				setCreate(
+					DTO{Scopes: []string{"users:read"}},
+					nil,
+					newValidationErr(ValidationError, "name is required"),
+				)
+			},
+			wantHTTPCode: http.StatusUnprocessableEntity,
+			want:         []byte(`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"name is required","instance":"/v1/api-keys","code":"VALIDATION_ERROR"}`),
+		},
+		{
+			name:         "invalid body",
+			body:         []byte(`[`),
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"body must be valid JSON","instance":"/v1/api-keys","code":"INVALID_API_KEY_DATA","errors":[{"rule":"json","message":"body must be valid JSON"}]}`),
+		},
+	}
+
+	e := NewEndpoint(zap.NewNop(), svc)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/api-keys", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			e.CreateKey(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+			assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, string(tt.want), string(data))
+		})
+	}
+}
+
+func TestEndpoint_RevokeKey(t *testing.T) {
+	svc := new(MockServer)
+
+	setRevoke := func(err error) {
+		svc.On("RevokeKey", mock.Anything, keyID).Return(err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		id           string
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "success",
+			id:   keyID.String(),
+			setup: func() {
+				setRevoke(nil)
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte(`{}`),
+		},
+		{
+			name: "not found",
+			id:   keyID.String(),
+			setup: func() {
+				setRevoke(newNotFoundErr(NotFound, "api key not found"))
+			},
+			wantHTTPCode: http.StatusNotFound,
+			want:         []byte(`{"type":"about:blank","title":"Not Found","status":404,"detail":"api key not found","instance":"/v1/api-keys/ccae37ea-d41e-4371-a3a3-89203b9e2608","code":"NOT_FOUND"}`),
+		},
+		{
+			name:         "invalid id",
+			id:           "invalid",
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/api-keys/invalid","code":"INVALID_API_KEY_ID"}`),
+		},
+	}
+
+	e := NewEndpoint(zap.NewNop(), svc)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodDelete, "/v1/api-keys/"+tt.id, nil)
+			w := httptest.NewRecorder()
+
+			serve("DELETE /v1/api-keys/{id}", e.RevokeKey, w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
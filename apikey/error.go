@@ -0,0 +1,54 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// service error codes.
+const (
+	InvalidKeyID        = "INVALID_API_KEY_ID"
+	InvalidKeyData      = "INVALID_API_KEY_DATA"
+	ScopeNotGranted     = "SCOPE_NOT_GRANTED"
+	InternalServerError = apperr.InternalServerError
+	NotFound            = apperr.NotFound
+	ValidationError     = apperr.ValidationError
+)
+
+var (
+	errNotExists  = errors.New("not exists")
+	errInvalidKey = errors.New("invalid api key")
+)
+
+// ServiceError represent service custom error.
+type ServiceError = apperr.ServiceError
+
+func newBadRequest(code, msg string) *ServiceError {
+	return apperr.NewBadRequest(code, msg)
+}
+
+func newInternalServer(code, msg string) *ServiceError {
+	return apperr.NewInternalServer(code, msg)
+}
+
+func newNotFoundErr(code, msg string) *ServiceError {
+	return apperr.NewNotFound(code, msg)
+}
+
+func newForbiddenErr(code, msg string) *ServiceError {
+	return apperr.New(http.StatusForbidden, code, msg)
+}
+
+func newValidationErr(code, msg string) *ServiceError {
+	return apperr.NewValidation(code, msg)
+}
+
+func newDecodeErr(code string, dst any, err error) *ServiceError {
+	return apperr.NewDecode(code, dst, err)
+}
+
+func newFieldValidationErr(code string, err error) *ServiceError {
+	return apperr.NewFieldValidation(code, err)
+}
@@ -0,0 +1,41 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// Key is an API key of a service-to-service caller, only a salted hash of its secret is stored.
+type Key struct {
+	ID         uuid.UUID      `json:"id"`
+	Name       string         `json:"name"`
+	Scopes     pq.StringArray `json:"scopes" swaggertype:"array,string"`
+	Hash       []byte         `json:"-"`
+	Salt       []byte         `json:"-"`
+	ExpiresAt  *time.Time     `db:"expires_at" json:"expiresAt"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"lastUsedAt"`
+	RevokedAt  *time.Time     `db:"revoked_at" json:"revokedAt"`
+	CreatedAt  time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// Issued is a newly created key along with its token, the token is shown only once.
+type Issued struct {
+	*Key
+	Token string `json:"token"`
+}
+
+// DTO represent data transfer object for issuing a new key.
+type DTO struct {
+	Name      string     `validate:"required" json:"name,omitempty"`
+	Scopes    []string   `validate:"dive,required" json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Validate check mandatory fields.
+func (d DTO) Validate() error {
+	return apperr.ValidateStruct(d)
+}
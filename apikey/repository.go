@@ -0,0 +1,144 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ihippik/template-service/metrics"
+	"github.com/ihippik/template-service/tenant"
+)
+
+// Repository is a database PostgreSQL repository.
+// Every query runs in a transaction bound to the tenant from the context.
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates new Repository instance.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// List receive all keys from the database.
+func (r *Repository) List(ctx context.Context) (_ []*Key, err error) {
+	defer metrics.ObserveQuery("apikey", "List", time.Now(), &err)
+
+	var models []*Key
+
+	err = tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(
+			ctx,
+			&models,
+			`SELECT id, name, scopes, hash, salt, expires_at, last_used_at, revoked_at, created_at
+			FROM api_keys ORDER BY created_at`,
+		)
+		if err != nil {
+			return fmt.Errorf("select: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+// Get receive key form the database by its id.
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (_ *Key, err error) {
+	defer metrics.ObserveQuery("apikey", "Get", time.Now(), &err)
+
+	var model Key
+
+	err = tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx,
+			`SELECT id, name, scopes, hash, salt, expires_at, last_used_at, revoked_at, created_at
+			FROM api_keys WHERE id=$1`,
+			id,
+		).StructScan(&model)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return errNotExists
+		case err != nil:
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+// Create new key in the database, the tenant column is filled from the transaction setting.
+func (r *Repository) Create(ctx context.Context, key *Key) (err error) {
+	defer metrics.ObserveQuery("apikey", "Create", time.Now(), &err)
+
+	return r.exec(
+		ctx,
+		`INSERT INTO api_keys (id, name, scopes, hash, salt, expires_at, created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`,
+		key.ID,
+		key.Name,
+		key.Scopes,
+		key.Hash,
+		key.Salt,
+		key.ExpiresAt,
+		key.CreatedAt,
+	)
+}
+
+// Revoke marks the key as revoked, errNotExists is returned for unknown or already revoked keys.
+func (r *Repository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) (err error) {
+	defer metrics.ObserveQuery("apikey", "Revoke", time.Now(), &err)
+
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(
+			ctx,
+			"UPDATE api_keys SET revoked_at=$1 WHERE id=$2 AND revoked_at IS NULL",
+			revokedAt,
+			id,
+		)
+		if err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		if affected == 0 {
+			return errNotExists
+		}
+
+		return nil
+	})
+}
+
+// Touch updates the last usage time of the key.
+func (r *Repository) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) (err error) {
+	defer metrics.ObserveQuery("apikey", "Touch", time.Now(), &err)
+
+	return r.exec(ctx, "UPDATE api_keys SET last_used_at=$1 WHERE id=$2", usedAt, id)
+}
+
+func (r *Repository) exec(ctx context.Context, query string, args ...any) error {
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		return nil
+	})
+}
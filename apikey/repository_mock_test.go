@@ -0,0 +1,38 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) Get(ctx context.Context, id uuid.UUID) (*Key, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Key), args.Error(1)
+}
+
+func (m *MockRepo) List(ctx context.Context) ([]*Key, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*Key), args.Error(1)
+}
+
+func (m *MockRepo) Create(ctx context.Context, key *Key) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockRepo) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	args := m.Called(ctx, id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepo) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
@@ -0,0 +1,179 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/tenant"
+)
+
+type repo struct {
+	sql  string
+	err  error
+	rows *sqlmock.Rows
+}
+
+var columns = []string{"id", "name", "scopes", "hash", "salt", "expires_at", "last_used_at", "revoked_at", "created_at"}
+
+func TestRepository_Get(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	query := prepareSQL(`SELECT id, name, scopes, hash, salt, expires_at, last_used_at, revoked_at, created_at
+			FROM api_keys WHERE id=$1`)
+
+	tests := []struct {
+		name    string
+		repo    repo
+		want    *Key
+		wantErr error
+	}{
+		{
+			name: "success",
+			repo: repo{
+				sql: query,
+				rows: sqlmock.NewRows(columns).AddRow(
+					keyID.String(),
+					"batch",
+					"{users:read,users:write}",
+					[]byte("hash"),
+					[]byte("salt"),
+					nil,
+					nil,
+					nil,
+					time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+				),
+			},
+			want: &Key{
+				ID:        keyID,
+				Name:      "batch",
+				Scopes:    pq.StringArray{"users:read", "users:write"},
+				Hash:      []byte("hash"),
+				Salt:      []byte("salt"),
+				CreatedAt: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			repo: repo{
+				sql:  query,
+				err:  sql.ErrNoRows,
+				rows: sqlmock.NewRows(columns),
+			},
+			want:    nil,
+			wantErr: errors.New("not exists"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+			mock.ExpectQuery(tt.repo.sql).
+				WithArgs(keyID).
+				WillReturnRows(tt.repo.rows).
+				WillReturnError(tt.repo.err)
+			expectTxEnd(mock, tt.wantErr)
+
+			got, err := r.Get(tenantCtx, keyID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRepository_Revoke(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	revokedAt := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		affected int64
+		err      error
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			affected: 1,
+			wantErr:  nil,
+		},
+		{
+			name:     "not found or already revoked",
+			affected: 0,
+			wantErr:  errors.New("not exists"),
+		},
+		{
+			name:    "some error",
+			err:     errors.New("some error"),
+			wantErr: errors.New("exec: some error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+			mock.ExpectExec(prepareSQL(`UPDATE api_keys SET revoked_at=$1 WHERE id=$2 AND revoked_at IS NULL`)).
+				WithArgs(revokedAt, keyID).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected)).
+				WillReturnError(tt.err)
+			expectTxEnd(mock, tt.wantErr)
+
+			err := r.Revoke(tenantCtx, keyID, revokedAt)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func prepareSQL(sql string) string {
+	replacer := strings.NewReplacer("$", "\\$", "(", "\\(", ")", "\\)")
+	return replacer.Replace(sql)
+}
+
+var tenantCtx = tenant.WithID(context.Background(), uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d"))
+
+func expectTenantTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(prepareSQL(`SELECT set_config('app.tenant_id', $1, true)`)).
+		WithArgs("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectTxEnd(mock sqlmock.Sqlmock, err error) {
+	if err != nil {
+		mock.ExpectRollback()
+		return
+	}
+
+	mock.ExpectCommit()
+}
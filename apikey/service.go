@@ -0,0 +1,235 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/logging"
+	"github.com/ihippik/template-service/rbac"
+	"github.com/ihippik/template-service/tenant"
+	"github.com/ihippik/template-service/tracing"
+)
+
+// Scheme is the Authorization header scheme of API keys.
+const Scheme = "ApiKey"
+
+type repository interface {
+	Get(ctx context.Context, id uuid.UUID) (*Key, error)
+	List(ctx context.Context) ([]*Key, error)
+	Create(ctx context.Context, key *Key) error
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+	Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// Service represent the API key management logic, it is also an auth.Authenticator.
+type Service struct {
+	cfg    *config.Config
+	logger *zap.Logger
+	repo   repository
+}
+
+var timeNow = time.Now
+
+// NewService creates new Service entity.
+func NewService(cfg *config.Config, logger *zap.Logger, repo repository) *Service {
+	return &Service{cfg: cfg, logger: logger, repo: repo}
+}
+
+// GetKey get key entity by its identification.
+func (svc *Service) GetKey(ctx context.Context, id uuid.UUID) (_ *Key, err error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.GetKey")
+	defer tracing.End(span, &err)
+
+	model, err := svc.repo.Get(ctx, id)
+	if err == nil {
+		return model, nil
+	}
+
+	if errors.Is(err, errNotExists) {
+		svc.log(ctx).Warn("api key not found", zap.String("id", id.String()))
+		return nil, newNotFoundErr(NotFound, "api key not found")
+	}
+
+	svc.log(ctx).Error("could not get api key", zap.Error(err))
+
+	return nil, fmt.Errorf("could not get api key: %w", err)
+}
+
+// ListKeys fetch all keys including revoked and expired ones.
+func (svc *Service) ListKeys(ctx context.Context) (_ []*Key, err error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.ListKeys")
+	defer tracing.End(span, &err)
+
+	models, err := svc.repo.List(ctx)
+	if err != nil {
+		svc.log(ctx).Error("could not fetch api keys", zap.Error(err))
+		return nil, fmt.Errorf("list: %w", err)
+	}
+
+	return models, nil
+}
+
+// CreateKey issues a new key for the tenant from the context,
+// the key can't be granted scopes the caller doesn't hold.
+func (svc *Service) CreateKey(ctx context.Context, dto DTO) (_ *Issued, err error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.CreateKey")
+	defer tracing.End(span, &err)
+
+	if err := dto.Validate(); err != nil {
+		svc.log(ctx).Warn("dto validation error", zap.Error(err))
+		return nil, newFieldValidationErr(ValidationError, err)
+	}
+
+	now := timeNow().UTC()
+
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(now) {
+		svc.log(ctx).Warn("api key expiration is in the past")
+		return nil, newValidationErr(ValidationError, "expiration must be in the future")
+	}
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+
+	if scope, missing := missingScope(ctx, dto.Scopes); missing {
+		svc.log(ctx).Warn("api key scope is not held by the caller", zap.String("scope", scope))
+		return nil, newForbiddenErr(ScopeNotGranted, fmt.Sprintf("scope %s is not granted to the caller", scope))
+	}
+
+	model := Key{
+		ID:        uuid.New(),
+		Name:      dto.Name,
+		Scopes:    pq.StringArray{},
+		ExpiresAt: dto.ExpiresAt,
+		CreatedAt: now,
+	}
+
+	model.Scopes = append(model.Scopes, dto.Scopes...)
+
+	token, secret, err := newToken(tenantID, model.ID)
+	if err != nil {
+		svc.log(ctx).Error("could not generate api key", zap.Error(err))
+		return nil, fmt.Errorf("generate token: %w", err)
+	}
+
+	if model.Salt, err = randomBytes(saltSize); err != nil {
+		svc.log(ctx).Error("could not generate salt", zap.Error(err))
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+
+	model.Hash = hashSecret(model.Salt, secret)
+
+	if err := svc.repo.Create(ctx, &model); err != nil {
+		svc.log(ctx).Error("could not create api key", zap.Error(err))
+		return nil, fmt.Errorf("could not create api key: %w", err)
+	}
+
+	return &Issued{Key: &model, Token: token}, nil
+}
+
+// missingScope returns the first scope granted neither to the principal of the context nor to its roles.
+func missingScope(ctx context.Context, scopes []string) (string, bool) {
+	var granted []string
+
+	if principal, ok := auth.FromContext(ctx); ok {
+		granted = principal.Scopes
+	}
+
+	roles, _ := rbac.RolesFromContext(ctx)
+
+	for _, scope := range scopes {
+		if !rbac.Allowed(rbac.Permission(scope), roles, granted) {
+			return scope, true
+		}
+	}
+
+	return "", false
+}
+
+// RevokeKey revoke a key by its identification, the key can not be used anymore.
+func (svc *Service) RevokeKey(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.RevokeKey")
+	defer tracing.End(span, &err)
+
+	err = svc.repo.Revoke(ctx, id, timeNow().UTC())
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, errNotExists) {
+		svc.log(ctx).Warn("active api key not found", zap.String("id", id.String()))
+		return newNotFoundErr(NotFound, "api key not found")
+	}
+
+	svc.log(ctx).Error("could not revoke api key", zap.Error(err))
+
+	return fmt.Errorf("revoke: %w", err)
+}
+
+// Scheme implements auth.Authenticator interface.
+func (svc *Service) Scheme() string {
+	return Scheme
+}
+
+// Authenticate checks the token against the stored hash and the key state,
+// the principal gets the key scopes and tenant.
+func (svc *Service) Authenticate(ctx context.Context, token string) (_ *auth.Principal, err error) {
+	ctx, span := tracing.Start(ctx, "apikey.Service.Authenticate")
+	defer tracing.End(span, &err)
+
+	tenantID, keyID, secret, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = tenant.WithID(ctx, tenantID)
+
+	model, err := svc.repo.Get(ctx, keyID)
+	if errors.Is(err, errNotExists) {
+		return nil, errInvalidKey
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get api key: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare(hashSecret(model.Salt, secret), model.Hash) != 1 {
+		return nil, errInvalidKey
+	}
+
+	now := timeNow().UTC()
+
+	if model.RevokedAt != nil {
+		return nil, errors.New("api key is revoked")
+	}
+
+	if model.ExpiresAt != nil && !now.Before(*model.ExpiresAt) {
+		return nil, errors.New("api key is expired")
+	}
+
+	if err := svc.repo.Touch(ctx, model.ID, now); err != nil {
+		svc.log(ctx).Warn("could not update api key last usage", zap.Error(err))
+	}
+
+	return &auth.Principal{
+		Subject:  model.ID.String(),
+		Method:   auth.MethodAPIKey,
+		TenantID: &tenantID,
+		Scopes:   model.Scopes,
+	}, nil
+}
+
+// log returns the request-scoped logger of the context.
+func (svc *Service) log(ctx context.Context) *zap.Logger {
+	return logging.FromContext(ctx, svc.logger)
+}
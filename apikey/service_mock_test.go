@@ -0,0 +1,32 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockServer struct {
+	mock.Mock
+}
+
+func (m *MockServer) GetKey(ctx context.Context, id uuid.UUID) (*Key, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Key), args.Error(1)
+}
+
+func (m *MockServer) ListKeys(ctx context.Context) ([]*Key, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*Key), args.Error(1)
+}
+
+func (m *MockServer) CreateKey(ctx context.Context, dto DTO) (*Issued, error) {
+	args := m.Called(ctx, dto)
+	return args.Get(0).(*Issued), args.Error(1)
+}
+
+func (m *MockServer) RevokeKey(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
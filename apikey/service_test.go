@@ -0,0 +1,408 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/rbac"
+	"github.com/ihippik/template-service/tenant"
+)
+
+var (
+	tenantID = uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d")
+	keyID    = uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+)
+
+func toPointer[T any](v T) *T {
+	return &v
+}
+
+func TestService_GetKey(t *testing.T) {
+	repo := new(MockRepo)
+
+	setGet := func(key *Key, err error) {
+		repo.On("Get", mock.Anything, keyID).Return(key, err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		want    *Key
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				setGet(&Key{ID: keyID, Name: "batch"}, nil)
+			},
+			want:    &Key{ID: keyID, Name: "batch"},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			setup: func() {
+				setGet(nil, errNotExists)
+			},
+			want:    nil,
+			wantErr: newNotFoundErr(NotFound, "api key not found"),
+		},
+		{
+			name: "some error",
+			setup: func() {
+				setGet(nil, errors.New("some error"))
+			},
+			want:    nil,
+			wantErr: errors.New("could not get api key: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.GetKey(context.Background(), keyID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_CreateKey(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	repo := new(MockRepo)
+
+	setCreate := func(err error) {
+		repo.On("Create", mock.Anything, mock.Anything).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		dto     DTO
+		setup   func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  rbac.WithRoles(tenant.WithID(context.Background(), tenantID), []string{rbac.RoleViewer}),
+			dto: DTO{
+				Name:      "batch",
+				Scopes:    []string{"users:read"},
+				ExpiresAt: toPointer(time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			setup: func() {
+				setCreate(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "scope of the principal",
+			ctx: auth.WithPrincipal(
+				tenant.WithID(context.Background(), tenantID),
+				&auth.Principal{Subject: "alice", Scopes: []string{"api_keys:manage", "users:delete"}},
+			),
+			dto: DTO{Name: "batch", Scopes: []string{"users:delete"}},
+			setup: func() {
+				setCreate(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "scope not held",
+			ctx: auth.WithPrincipal(
+				rbac.WithRoles(tenant.WithID(context.Background(), tenantID), []string{rbac.RoleEditor}),
+				&auth.Principal{Subject: "alice", Scopes: []string{"api_keys:manage"}},
+			),
+			dto:     DTO{Name: "batch", Scopes: []string{"users:read", "role_bindings:manage"}},
+			setup:   func() {},
+			wantErr: newForbiddenErr(ScopeNotGranted, "scope role_bindings:manage is not granted to the caller"),
+		},
+		{
+			name:    "validation error",
+			ctx:     tenant.WithID(context.Background(), tenantID),
+			dto:     DTO{Scopes: []string{"users:read"}},
+			setup:   func() {},
+			wantErr: newValidationErr(ValidationError, "name is required"),
+		},
+		{
+			name: "expired",
+			ctx:  tenant.WithID(context.Background(), tenantID),
+			dto: DTO{
+				Name:      "batch",
+				ExpiresAt: toPointer(time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			setup:   func() {},
+			wantErr: newValidationErr(ValidationError, "expiration must be in the future"),
+		},
+		{
+			name:    "no tenant",
+			ctx:     context.Background(),
+			dto:     DTO{Name: "batch"},
+			setup:   func() {},
+			wantErr: tenant.ErrMissing,
+		},
+		{
+			name: "repo error",
+			ctx:  tenant.WithID(context.Background(), tenantID),
+			dto:  DTO{Name: "batch"},
+			setup: func() {
+				setCreate(errors.New("some error"))
+			},
+			wantErr: errors.New("could not create api key: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.CreateKey(tt.ctx, tt.dto)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+
+			require.NoError(t, tt.wantErr)
+
+			assert.Equal(t, tt.dto.Name, got.Name)
+			assert.Equal(t, pq.StringArray(tt.dto.Scopes), got.Scopes)
+			assert.Equal(t, tt.dto.ExpiresAt, got.ExpiresAt)
+			assert.Equal(t, timeNow(), got.CreatedAt)
+
+			gotTenant, gotKey, secret, err := parseToken(got.Token)
+			assert.NoError(t, err)
+			assert.Equal(t, tenantID, gotTenant)
+			assert.Equal(t, got.ID, gotKey)
+			assert.Equal(t, got.Hash, hashSecret(got.Salt, secret))
+		})
+	}
+}
+
+func TestService_RevokeKey(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	repo := new(MockRepo)
+
+	setRevoke := func(err error) {
+		repo.On("Revoke", mock.Anything, keyID, timeNow()).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				setRevoke(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			setup: func() {
+				setRevoke(errNotExists)
+			},
+			wantErr: newNotFoundErr(NotFound, "api key not found"),
+		},
+		{
+			name: "some error",
+			setup: func() {
+				setRevoke(errors.New("some error"))
+			},
+			wantErr: errors.New("revoke: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			err := svc.RevokeKey(context.Background(), keyID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	token, secret, err := newToken(tenantID, keyID)
+	require.NoError(t, err)
+
+	salt := []byte("0123456789abcdef")
+
+	key := func(modify func(k *Key)) *Key {
+		k := Key{
+			ID:     keyID,
+			Name:   "batch",
+			Scopes: pq.StringArray{"users:read"},
+			Hash:   hashSecret(salt, secret),
+			Salt:   salt,
+		}
+
+		if modify != nil {
+			modify(&k)
+		}
+
+		return &k
+	}
+
+	repo := new(MockRepo)
+
+	tenantCtx := mock.MatchedBy(func(ctx context.Context) bool {
+		id, ok := tenant.FromContext(ctx)
+		return ok && id == tenantID
+	})
+
+	setGet := func(key *Key, err error) {
+		repo.On("Get", tenantCtx, keyID).Return(key, err).Once()
+	}
+
+	setTouch := func(err error) {
+		repo.On("Touch", tenantCtx, keyID, timeNow()).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		setup   func()
+		want    *auth.Principal
+		wantErr error
+	}{
+		{
+			name:  "success",
+			token: token,
+			setup: func() {
+				setGet(key(nil), nil)
+				setTouch(nil)
+			},
+			want: &auth.Principal{
+				Subject:  keyID.String(),
+				Method:   auth.MethodAPIKey,
+				TenantID: &tenantID,
+				Scopes:   []string{"users:read"},
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "touch error is ignored",
+			token: token,
+			setup: func() {
+				setGet(key(nil), nil)
+				setTouch(errors.New("some error"))
+			},
+			want: &auth.Principal{
+				Subject:  keyID.String(),
+				Method:   auth.MethodAPIKey,
+				TenantID: &tenantID,
+				Scopes:   []string{"users:read"},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "malformed",
+			token:   "invalid",
+			setup:   func() {},
+			wantErr: errMalformedToken,
+		},
+		{
+			name:  "not found",
+			token: token,
+			setup: func() {
+				setGet(nil, errNotExists)
+			},
+			wantErr: errInvalidKey,
+		},
+		{
+			name:  "wrong secret",
+			token: token,
+			setup: func() {
+				setGet(key(func(k *Key) { k.Hash = hashSecret(salt, []byte("other")) }), nil)
+			},
+			wantErr: errInvalidKey,
+		},
+		{
+			name:  "revoked",
+			token: token,
+			setup: func() {
+				setGet(key(func(k *Key) { k.RevokedAt = toPointer(time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)) }), nil)
+			},
+			wantErr: errors.New("api key is revoked"),
+		},
+		{
+			name:  "expired",
+			token: token,
+			setup: func() {
+				setGet(key(func(k *Key) { k.ExpiresAt = toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)) }), nil)
+			},
+			wantErr: errors.New("api key is expired"),
+		},
+		{
+			name:  "repo error",
+			token: token,
+			setup: func() {
+				setGet(nil, errors.New("some error"))
+			},
+			wantErr: errors.New("could not get api key: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.Authenticate(context.Background(), tt.token)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
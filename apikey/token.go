@@ -0,0 +1,72 @@
+package apikey
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// tokenPrefix makes leaked tokens easy to spot by secret scanners.
+const tokenPrefix = "tsk_"
+
+const (
+	saltSize   = 16
+	secretSize = 32
+)
+
+var errMalformedToken = errors.New("malformed api key")
+
+// newToken generates a random secret and builds the token "tsk_<tenant id|key id>.<secret>".
+// The tenant is a part of the token because keys are looked up under row-level security.
+func newToken(tenantID, keyID uuid.UUID) (string, []byte, error) {
+	secret, err := randomBytes(secretSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ids := append(tenantID[:], keyID[:]...)
+
+	return tokenPrefix +
+		base64.RawURLEncoding.EncodeToString(ids) + "." +
+		base64.RawURLEncoding.EncodeToString(secret), secret, nil
+}
+
+// parseToken splits the token into the tenant id, the key id and the secret.
+func parseToken(token string) (uuid.UUID, uuid.UUID, []byte, error) {
+	rawIDs, rawSecret, ok := strings.Cut(strings.TrimPrefix(token, tokenPrefix), ".")
+	if !ok || !strings.HasPrefix(token, tokenPrefix) {
+		return uuid.Nil, uuid.Nil, nil, errMalformedToken
+	}
+
+	ids, err := base64.RawURLEncoding.DecodeString(rawIDs)
+	if err != nil || len(ids) != 32 {
+		return uuid.Nil, uuid.Nil, nil, errMalformedToken
+	}
+
+	secret, err := base64.RawURLEncoding.DecodeString(rawSecret)
+	if err != nil || len(secret) != secretSize {
+		return uuid.Nil, uuid.Nil, nil, errMalformedToken
+	}
+
+	return uuid.UUID(ids[:16]), uuid.UUID(ids[16:]), secret, nil
+}
+
+func hashSecret(salt, secret []byte) []byte {
+	sum := sha256.Sum256(bytes.Join([][]byte{salt, secret}, nil))
+	return sum[:]
+}
+
+func randomBytes(n int) ([]byte, error) {
+	data := make([]byte, n)
+
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,47 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	token, secret, err := newToken(tenantID, keyID)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, tokenPrefix))
+	assert.Len(t, secret, secretSize)
+
+	gotTenant, gotKey, gotSecret, err := parseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, tenantID, gotTenant)
+	assert.Equal(t, keyID, gotKey)
+	assert.Equal(t, secret, gotSecret)
+}
+
+func TestParseToken(t *testing.T) {
+	token, _, err := newToken(uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "no prefix", token: strings.TrimPrefix(token, tokenPrefix)},
+		{name: "no secret", token: strings.Split(token, ".")[0]},
+		{name: "short ids", token: tokenPrefix + "AQID." + strings.Split(token, ".")[1]},
+		{name: "short secret", token: strings.Split(token, ".")[0] + ".AQID"},
+		{name: "invalid encoding", token: tokenPrefix + "!!!.!!!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := parseToken(tt.token)
+			assert.ErrorIs(t, err, errMalformedToken)
+		})
+	}
+}
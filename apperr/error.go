@@ -0,0 +1,124 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+)
+
+// common service error codes.
+const (
+	InternalServerError = "INTERNAL_SERVER_ERROR"
+	NotFound            = "NOT_FOUND"
+	ValidationError     = "VALIDATION_ERROR"
+)
+
+// ServiceError represent service custom error.
+type ServiceError struct {
+	HTTPCode   int            `json:"-"`
+	Code       string         `json:"code,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	Extensions map[string]any `json:"-"`
+
+	// fromFields is set when the message is composed of the field errors.
+	fromFields bool
+}
+
+// Error implement Error interface.
+func (e ServiceError) Error() string {
+	return e.Message
+}
+
+// With returns a copy of the error with the problem extension member.
+func (e ServiceError) With(key string, value any) *ServiceError {
+	ext := make(map[string]any, len(e.Extensions)+1)
+
+	for k, v := range e.Extensions {
+		ext[k] = v
+	}
+
+	ext[key] = value
+	e.Extensions = ext
+
+	return &e
+}
+
+// legacyError is the legacy error body, the invalid fields are listed under errors.
+type legacyError struct {
+	Code    string       `json:"code,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// ErrInternalServer is returned to the client when the error is not a ServiceError.
+var ErrInternalServer = &ServiceError{
+	HTTPCode: http.StatusInternalServerError,
+	Code:     InternalServerError,
+	Message:  "internal server error",
+}
+
+// New creates new ServiceError with the specified http status.
+func New(httpCode int, code, msg string) *ServiceError {
+	return &ServiceError{HTTPCode: httpCode, Code: code, Message: msg}
+}
+
+// NewBadRequest creates new 400 ServiceError.
+func NewBadRequest(code, msg string) *ServiceError {
+	return New(http.StatusBadRequest, code, msg)
+}
+
+// NewInternalServer creates new 500 ServiceError.
+func NewInternalServer(code, msg string) *ServiceError {
+	return New(http.StatusInternalServerError, code, msg)
+}
+
+// NewNotFound creates new 404 ServiceError.
+func NewNotFound(code, msg string) *ServiceError {
+	return New(http.StatusNotFound, code, msg)
+}
+
+// NewValidation creates new 422 ServiceError.
+func NewValidation(code, msg string) *ServiceError {
+	return New(http.StatusUnprocessableEntity, code, msg)
+}
+
+// Write writes the error of the request to the client in the configured format and
+// the language of Accept-Language, unknown errors are hidden behind ErrInternalServer.
+func Write(w http.ResponseWriter, r *http.Request, logger *zap.Logger, err error) {
+	var (
+		svcErr *ServiceError
+		body   any
+	)
+
+	if !errors.As(err, &svcErr) {
+		svcErr = ErrInternalServer
+	}
+
+	trans := translatorFor(r)
+	svcErr = localize(trans, svcErr)
+
+	w.Header().Set("Content-Language", trans.Locale())
+
+	if format == FormatLegacy {
+		fields, _ := svcErr.Extensions[FieldsExtension].([]FieldError)
+		body = legacyError{Code: svcErr.Code, Message: svcErr.Message, Errors: fields}
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		body = NewProblem(r, svcErr)
+		w.Header().Set("Content-Type", ProblemContentType)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		logger.Error("marshal server err", zap.Error(err))
+		return
+	}
+
+	w.WriteHeader(svcErr.HTTPCode)
+
+	if _, err = w.Write(data); err != nil {
+		logger.Error("write error", zap.Error(err))
+	}
+}
@@ -0,0 +1,113 @@
+package apperr
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          string
+		err             error
+		wantHTTPCode    int
+		wantContentType string
+		want            []byte
+	}{
+		{
+			name:            "service error",
+			format:          FormatProblem,
+			err:             NewNotFound(NotFound, "user not found"),
+			wantHTTPCode:    http.StatusNotFound,
+			wantContentType: ProblemContentType,
+			want:            []byte(`{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found","instance":"/v1/users/1","code":"NOT_FOUND"}`),
+		},
+		{
+			name:            "wrapped service error",
+			format:          FormatProblem,
+			err:             errors.Join(errors.New("some error"), NewValidation(ValidationError, "invalid")),
+			wantHTTPCode:    http.StatusUnprocessableEntity,
+			wantContentType: ProblemContentType,
+			want:            []byte(`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"invalid","instance":"/v1/users/1","code":"VALIDATION_ERROR"}`),
+		},
+		{
+			name:            "extensions",
+			format:          FormatProblem,
+			err:             NewBadRequest("INVALID_USER_DATA", "invalid").With("field", "birthday"),
+			wantHTTPCode:    http.StatusBadRequest,
+			wantContentType: ProblemContentType,
+			want:            []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid","instance":"/v1/users/1","code":"INVALID_USER_DATA","field":"birthday"}`),
+		},
+		{
+			name:            "unknown error",
+			format:          FormatProblem,
+			err:             errors.New("some error"),
+			wantHTTPCode:    http.StatusInternalServerError,
+			wantContentType: ProblemContentType,
+			want:            []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users/1","code":"INTERNAL_SERVER_ERROR"}`),
+		},
+		{
+			name:            "legacy",
+			format:          FormatLegacy,
+			err:             NewNotFound(NotFound, "user not found").With("field", "id"),
+			wantHTTPCode:    http.StatusNotFound,
+			wantContentType: "application/json",
+			want:            []byte(`{"code":"NOT_FOUND","message":"user not found"}`),
+		},
+		{
+			name:   "legacy fields",
+			format: FormatLegacy,
+			err: NewValidation(ValidationError, "name is required").
+				With(FieldsExtension, []FieldError{{Field: "name", Rule: "required", Message: "name is required"}}),
+			wantHTTPCode:    http.StatusUnprocessableEntity,
+			wantContentType: "application/json",
+			want:            []byte(`{"code":"VALIDATION_ERROR","message":"name is required","errors":[{"field":"name","rule":"required","message":"name is required"}]}`),
+		},
+		{
+			name:            "legacy unknown error",
+			format:          FormatLegacy,
+			err:             errors.New("some error"),
+			wantHTTPCode:    http.StatusInternalServerError,
+			wantContentType: "application/json",
+			want:            []byte(`{"code":"INTERNAL_SERVER_ERROR","message":"internal server error"}`),
+		},
+	}
+
+	t.Cleanup(func() { format = FormatProblem })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format = tt.format
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/1?fields=id", nil)
+			w := httptest.NewRecorder()
+
+			Write(w, req, zap.NewNop(), tt.err)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+			assert.Equal(t, tt.wantContentType, res.Header.Get("Content-Type"))
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestServiceError_With(t *testing.T) {
+	base := NewBadRequest("INVALID_USER_DATA", "invalid")
+	got := base.With("field", "birthday")
+
+	assert.Nil(t, base.Extensions)
+	assert.Equal(t, map[string]any{"field": "birthday"}, got.Extensions)
+	assert.Equal(t, "invalid", got.Error())
+}
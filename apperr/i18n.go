@@ -0,0 +1,209 @@
+package apperr
+
+import (
+	"embed"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/goccy/go-json"
+)
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalog is a message catalog of the locale, messages are keyed by their English text.
+type catalog struct {
+	Titles   map[string]string `json:"titles"`
+	Messages map[string]string `json:"messages"`
+	Rules    map[string]string `json:"rules"`
+}
+
+// translation keys are typed to keep catalog sections apart.
+type (
+	titleKey   string
+	messageKey string
+	ruleKey    string
+)
+
+const defaultRule = "default"
+
+var translator = mustTranslator(en.New(), ru.New(), de.New())
+
+func mustTranslator(fallback locales.Translator, supported ...locales.Translator) *ut.UniversalTranslator {
+	uni, err := newTranslator(fallback, supported...)
+	if err != nil {
+		panic(err)
+	}
+
+	return uni
+}
+
+func newTranslator(fallback locales.Translator, supported ...locales.Translator) (*ut.UniversalTranslator, error) {
+	uni := ut.New(fallback, append([]locales.Translator{fallback}, supported...)...)
+
+	for _, l := range append([]locales.Translator{fallback}, supported...) {
+		data, err := localeFS.ReadFile(path.Join("locales", l.Locale()+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("read %s catalog: %w", l.Locale(), err)
+		}
+
+		var c catalog
+
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("parse %s catalog: %w", l.Locale(), err)
+		}
+
+		trans, _ := uni.GetTranslator(l.Locale())
+
+		if err := c.addTo(trans); err != nil {
+			return nil, fmt.Errorf("load %s catalog: %w", l.Locale(), err)
+		}
+	}
+
+	return uni, nil
+}
+
+func (c catalog) addTo(trans ut.Translator) error {
+	for k, v := range c.Titles {
+		if err := trans.Add(titleKey(k), v, false); err != nil {
+			return err
+		}
+	}
+
+	for k, v := range c.Messages {
+		if err := trans.Add(messageKey(k), v, false); err != nil {
+			return err
+		}
+	}
+
+	for k, v := range c.Rules {
+		if err := trans.Add(ruleKey(k), v, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// translatorFor finds the translator for the Accept-Language of the request, English is the fallback.
+func translatorFor(r *http.Request) ut.Translator {
+	if r == nil {
+		return translator.GetFallback()
+	}
+
+	trans, _ := translator.FindTranslator(acceptLanguages(r.Header.Get("Accept-Language"))...)
+
+	return trans
+}
+
+// acceptLanguages returns locales of the Accept-Language header by preference,
+// a regional tag is followed by its base language: ru-RU -> ru_ru, ru.
+func acceptLanguages(header string) []string {
+	type tag struct {
+		name string
+		q    float64
+	}
+
+	var tags []tag
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if name == "" || name == "*" {
+			continue
+		}
+
+		q := 1.0
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if q > 0 {
+			tags = append(tags, tag{name: strings.ToLower(name), q: q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	langs := make([]string, 0, len(tags)*2)
+
+	for _, t := range tags {
+		langs = append(langs, strings.ReplaceAll(t.name, "-", "_"))
+
+		if base, _, ok := strings.Cut(t.name, "-"); ok {
+			langs = append(langs, base)
+		}
+	}
+
+	return langs
+}
+
+// translate returns the translation of the key or the fallback text when there is none.
+func translate(trans ut.Translator, key any, fallback string, params ...string) string {
+	text, err := trans.T(key, params...)
+	if err != nil {
+		return fallback
+	}
+
+	return text
+}
+
+// ruleMessage describes the field failed the rule in the language of the translator.
+func ruleMessage(trans ut.Translator, fe FieldError) string {
+	if text, err := trans.T(ruleKey(fe.Rule), fe.Field, fe.Param); err == nil {
+		return text
+	}
+
+	rule := fe.Rule
+	if fe.Param != "" {
+		rule += "=" + fe.Param
+	}
+
+	return translate(trans, ruleKey(defaultRule), fe.Field+" failed on the "+rule+" rule", fe.Field, rule)
+}
+
+// localize translates the error to the language of the translator, messages without translation stay as is.
+func localize(trans ut.Translator, e *ServiceError) *ServiceError {
+	if trans.Locale() == translator.GetFallback().Locale() {
+		return e
+	}
+
+	loc := *e
+
+	fields, ok := e.Extensions[FieldsExtension].([]FieldError)
+	if ok {
+		locFields := make([]FieldError, len(fields))
+		msgs := make([]string, len(fields))
+
+		for i, fe := range fields {
+			fe.Message = ruleMessage(trans, fe)
+			locFields[i] = fe
+			msgs[i] = fe.Message
+		}
+
+		loc = *loc.With(FieldsExtension, locFields)
+
+		if e.fromFields {
+			loc.Message = strings.Join(msgs, "; ")
+		}
+	}
+
+	if !e.fromFields {
+		loc.Message = translate(trans, messageKey(e.Message), e.Message)
+	}
+
+	return &loc
+}
@@ -0,0 +1,136 @@
+package apperr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAcceptLanguages(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{
+			name:   "empty",
+			header: "",
+			want:   []string{},
+		},
+		{
+			name:   "regional",
+			header: "ru-RU",
+			want:   []string{"ru_ru", "ru"},
+		},
+		{
+			name:   "quality",
+			header: "en;q=0.5, de-CH, fr;q=0, ru;q=0.8, *;q=0.1",
+			want:   []string{"de_ch", "de", "ru", "en"},
+		},
+		{
+			name:   "invalid quality",
+			header: "de;q=high, ru",
+			want:   []string{"ru"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, acceptLanguages(tt.header))
+		})
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	read := func(locale string) catalog {
+		data, err := localeFS.ReadFile(path.Join("locales", locale+".json"))
+		require.NoError(t, err)
+
+		var c catalog
+
+		require.NoError(t, json.Unmarshal(data, &c))
+
+		return c
+	}
+
+	base := read("en")
+
+	for _, locale := range []string{"ru", "de"} {
+		c := read(locale)
+
+		for rule := range base.Rules {
+			assert.Contains(t, c.Rules, rule, "locale %s", locale)
+		}
+	}
+}
+
+func TestWrite_localized(t *testing.T) {
+	type dto struct {
+		Name string `json:"name" validate:"required"`
+		Size int    `json:"size" validate:"max=10"`
+	}
+
+	tests := []struct {
+		name         string
+		lang         string
+		err          error
+		wantLanguage string
+		want         string
+	}{
+		{
+			name:         "translated",
+			lang:         "ru-RU,en;q=0.5",
+			err:          NewNotFound(NotFound, "user not found"),
+			wantLanguage: "ru",
+			want:         `{"type":"about:blank","title":"Не найдено","status":404,"detail":"пользователь не найден","instance":"/v1/users","code":"NOT_FOUND"}`,
+		},
+		{
+			name:         "no translation",
+			lang:         "de",
+			err:          NewBadRequest("INVALID_USER_ID", "invalid UUID length: 7"),
+			wantLanguage: "de",
+			want:         `{"type":"about:blank","title":"Ungültige Anfrage","status":400,"detail":"invalid UUID length: 7","instance":"/v1/users","code":"INVALID_USER_ID"}`,
+		},
+		{
+			name:         "fallback",
+			lang:         "fr",
+			err:          NewNotFound(NotFound, "user not found"),
+			wantLanguage: "en",
+			want:         `{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found","instance":"/v1/users","code":"NOT_FOUND"}`,
+		},
+		{
+			name:         "fields",
+			lang:         "de",
+			err:          NewFieldValidation(ValidationError, ValidateStruct(dto{Size: 11})),
+			wantLanguage: "de",
+			want:         `{"type":"about:blank","title":"Nicht verarbeitbare Daten","status":422,"detail":"name ist erforderlich; size darf höchstens 10 sein","instance":"/v1/users","code":"VALIDATION_ERROR","errors":[{"field":"name","rule":"required","message":"name ist erforderlich"},{"field":"size","rule":"max","param":"10","message":"size darf höchstens 10 sein"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			req.Header.Set("Accept-Language", tt.lang)
+
+			w := httptest.NewRecorder()
+
+			Write(w, req, zap.NewNop(), tt.err)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantLanguage, res.Header.Get("Content-Language"))
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package apperr
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/ihippik/template-service/config"
+)
+
+// error body formats.
+const (
+	FormatProblem = "problem"
+	FormatLegacy  = "legacy"
+)
+
+// ProblemContentType is the media type of RFC 7807 error bodies.
+const ProblemContentType = "application/problem+json"
+
+// blankType is the RFC 7807 type of problems without additional semantics.
+const blankType = "about:blank"
+
+var (
+	format   = FormatProblem
+	typeBase string
+)
+
+// Configure sets the format of error bodies, it must be called before the server starts.
+func Configure(cfg config.ErrorCfg) error {
+	switch cfg.Format {
+	case FormatProblem, FormatLegacy:
+	default:
+		return fmt.Errorf("unknown error format %q", cfg.Format)
+	}
+
+	format = cfg.Format
+	typeBase = strings.TrimSuffix(cfg.TypeBase, "/")
+
+	return nil
+}
+
+// Problem is an RFC 7807 problem details object, code and extensions are extension members.
+type Problem struct {
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Status     int            `json:"status"`
+	Detail     string         `json:"detail,omitempty"`
+	Instance   string         `json:"instance,omitempty"`
+	Code       string         `json:"code,omitempty"`
+	Extensions map[string]any `json:"-"`
+}
+
+// reserved members can't be overridden by extensions.
+var reserved = map[string]struct{}{
+	"type": {}, "title": {}, "status": {}, "detail": {}, "instance": {}, "code": {},
+}
+
+// MarshalJSON implement json.Marshaler, extensions are inlined into the object.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+
+	data, err := json.Marshal(problem(p))
+	if err != nil {
+		return nil, err
+	}
+
+	ext := make(map[string]any, len(p.Extensions))
+
+	for k, v := range p.Extensions {
+		if _, ok := reserved[k]; !ok {
+			ext[k] = v
+		}
+	}
+
+	if len(ext) == 0 {
+		return data, nil
+	}
+
+	extData, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.Grow(len(data) + len(extData))
+	buf.Write(data[:len(data)-1])
+	buf.WriteByte(',')
+	buf.Write(extData[1:])
+
+	return buf.Bytes(), nil
+}
+
+// NewProblem converts ServiceError to the problem of the request, the title is translated.
+func NewProblem(r *http.Request, e *ServiceError) *Problem {
+	title := http.StatusText(e.HTTPCode)
+
+	p := Problem{
+		Type:       problemType(e.Code),
+		Title:      translate(translatorFor(r), titleKey(title), title),
+		Status:     e.HTTPCode,
+		Detail:     e.Message,
+		Code:       e.Code,
+		Extensions: e.Extensions,
+	}
+
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+
+	return &p
+}
+
+func problemType(code string) string {
+	if typeBase == "" || code == "" {
+		return blankType
+	}
+
+	return typeBase + "/" + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
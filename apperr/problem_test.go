@@ -0,0 +1,57 @@
+package apperr
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/config"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() {
+		format = FormatProblem
+		typeBase = ""
+	})
+
+	err := Configure(config.ErrorCfg{Format: "xml"})
+	assert.EqualError(t, err, `unknown error format "xml"`)
+
+	err = Configure(config.ErrorCfg{Format: FormatLegacy, TypeBase: "https://example.com/problems/"})
+	assert.NoError(t, err)
+	assert.Equal(t, FormatLegacy, format)
+	assert.Equal(t, "https://example.com/problems", typeBase)
+}
+
+func TestNewProblem(t *testing.T) {
+	t.Cleanup(func() { typeBase = "" })
+
+	typeBase = "https://example.com/problems"
+
+	got := NewProblem(nil, NewNotFound("GROUP_NOT_FOUND", "group not found"))
+	assert.Equal(t, &Problem{
+		Type:   "https://example.com/problems/group-not-found",
+		Title:  "Not Found",
+		Status: http.StatusNotFound,
+		Detail: "group not found",
+		Code:   "GROUP_NOT_FOUND",
+	}, got)
+}
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	p := Problem{
+		Type:   blankType,
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Extensions: map[string]any{
+			"status": 200,
+			"errors": []string{"name"},
+		},
+	}
+
+	data, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"about:blank","title":"Bad Request","status":400,"errors":["name"]}`, string(data))
+}
@@ -0,0 +1,239 @@
+package apperr
+
+import (
+	"encoding"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/goccy/go-json"
+)
+
+// FieldsExtension is the problem extension member listing invalid fields.
+const FieldsExtension = "errors"
+
+// rules of decode errors.
+const (
+	jsonRule    = "json"
+	emptyRule   = "empty"
+	unknownRule = "unknown"
+	typeRule    = "type"
+)
+
+// unknownFieldPrefix starts the decoder error of a field missing in the destination.
+const unknownFieldPrefix = "json: unknown field "
+
+// FieldError describes an invalid field of the request body by its JSON name.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// FieldErrors lists the invalid fields found by custom validation, messages are made from the rules.
+type FieldErrors []FieldError
+
+// Error implements error interface.
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+
+	for _, fe := range e {
+		msgs = append(msgs, ruleMessage(translator.GetFallback(), fe))
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+var (
+	validate        = newValidator()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		if name == "" {
+			return f.Name
+		}
+
+		return name
+	})
+
+	return v
+}
+
+// ValidateStruct validates the struct by its validate tags, fields are reported by JSON names.
+func ValidateStruct(s any) error {
+	return validate.Struct(s)
+}
+
+// NewFieldValidation creates new 422 ServiceError listing the fields failed validation,
+// err is either validator.ValidationErrors or FieldErrors.
+func NewFieldValidation(code string, err error) *ServiceError {
+	var (
+		vErrs  validator.ValidationErrors
+		fields FieldErrors
+	)
+
+	switch {
+	case errors.As(err, &vErrs):
+		for _, fe := range vErrs {
+			fields = append(fields, FieldError{Field: fieldPath(fe.Namespace()), Rule: fe.Tag(), Param: fe.Param()})
+		}
+	case errors.As(err, &fields):
+	default:
+		return NewValidation(code, err.Error())
+	}
+
+	return newFields(NewValidation(code, ""), fields)
+}
+
+// NewDecode creates new 400 ServiceError for the body which could not be decoded into dst.
+// Decoder errors name Go types, so they are reported by the rule of the field instead.
+func NewDecode(code string, dst any, err error) *ServiceError {
+	var (
+		typeErr *json.UnmarshalTypeError
+		field   FieldError
+	)
+
+	switch {
+	case errors.As(err, &typeErr):
+		field = FieldError{
+			Field: jsonFieldName(reflect.TypeOf(dst), typeErr.Struct, typeErr.Field),
+			Rule:  typeRule,
+			Param: jsonType(typeErr.Type),
+		}
+	case errors.Is(err, io.EOF):
+		field = FieldError{Rule: emptyRule}
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		name, uErr := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix))
+		if uErr != nil {
+			field = FieldError{Rule: jsonRule}
+			break
+		}
+
+		field = FieldError{Field: name, Rule: unknownRule}
+	default:
+		field = FieldError{Rule: jsonRule}
+	}
+
+	return newFields(NewBadRequest(code, ""), []FieldError{field})
+}
+
+// newFields sets the messages of the fields and composes the error message of them.
+func newFields(svcErr *ServiceError, fields []FieldError) *ServiceError {
+	trans := translator.GetFallback()
+	res := make([]FieldError, 0, len(fields))
+	msgs := make([]string, 0, len(fields))
+
+	for _, fe := range fields {
+		fe.Message = ruleMessage(trans, fe)
+
+		res = append(res, fe)
+		msgs = append(msgs, fe.Message)
+	}
+
+	svcErr.Message = strings.Join(msgs, "; ")
+	svcErr = svcErr.With(FieldsExtension, res)
+	svcErr.fromFields = true
+
+	return svcErr
+}
+
+// jsonFieldName looks up the JSON name of the Go struct field in the type tree,
+// the decoder reports only the Go name of the field.
+func jsonFieldName(t reflect.Type, structName, goName string) string {
+	seen := make(map[reflect.Type]struct{})
+
+	var find func(t reflect.Type) (string, bool)
+
+	find = func(t reflect.Type) (string, bool) {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice ||
+			t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+
+		if t == nil || t.Kind() != reflect.Struct {
+			return "", false
+		}
+
+		if _, ok := seen[t]; ok {
+			return "", false
+		}
+
+		seen[t] = struct{}{}
+
+		if structName == "" || t.Name() == structName {
+			if f, ok := t.FieldByName(goName); ok {
+				if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+					return name, true
+				}
+
+				return goName, true
+			}
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			if name, ok := find(t.Field(i).Type); ok {
+				return name, true
+			}
+		}
+
+		return "", false
+	}
+
+	if name, ok := find(t); ok {
+		return name
+	}
+
+	return goName
+}
+
+// fieldPath strips the root struct name from the validator namespace: DTO.scopes[0] -> scopes[0].
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+
+	return namespace
+}
+
+// jsonType names the JSON type expected for the Go type.
+func jsonType(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+
+	if reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	default:
+		return "value"
+	}
+}
@@ -0,0 +1,107 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+type nested struct {
+	Port int `json:"port" validate:"min=1"`
+}
+
+type dto struct {
+	FirstName string     `json:"firstName,omitempty" validate:"required"`
+	Scopes    []string   `json:"scopes" validate:"dive,required"`
+	Kind      string     `json:"kind" validate:"omitempty,oneof=a b"`
+	Server    nested     `json:"server"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func TestNewFieldValidation(t *testing.T) {
+	err := ValidateStruct(dto{Scopes: []string{"users:read", ""}, Kind: "c"})
+
+	got := NewFieldValidation(ValidationError, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, got.HTTPCode)
+	assert.Equal(t, ValidationError, got.Code)
+	assert.Equal(
+		t,
+		"firstName is required; scopes[1] is required; kind must be one of [a b]; server.port must be at least 1",
+		got.Message,
+	)
+	assert.Equal(t, []FieldError{
+		{Field: "firstName", Rule: "required", Message: "firstName is required"},
+		{Field: "scopes[1]", Rule: "required", Message: "scopes[1] is required"},
+		{Field: "kind", Rule: "oneof", Param: "a b", Message: "kind must be one of [a b]"},
+		{Field: "server.port", Rule: "min", Param: "1", Message: "server.port must be at least 1"},
+	}, got.Extensions[FieldsExtension])
+
+	got = NewFieldValidation(ValidationError, FieldErrors{{Field: "team", Rule: "oneof", Param: "core infra"}})
+	assert.Equal(t, "team must be one of [core infra]", got.Message)
+	assert.Equal(t, []FieldError{
+		{Field: "team", Rule: "oneof", Param: "core infra", Message: "team must be one of [core infra]"},
+	}, got.Extensions[FieldsExtension])
+
+	got = NewFieldValidation(ValidationError, errors.New("some err"))
+	assert.Equal(t, NewValidation(ValidationError, "some err"), got)
+}
+
+func TestNewDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		strict bool
+		want   []FieldError
+	}{
+		{
+			name: "syntax",
+			body: `invalid`,
+			want: []FieldError{{Rule: "json", Message: "body must be valid JSON"}},
+		},
+		{
+			name: "type",
+			body: `{"firstName":1}`,
+			want: []FieldError{{Field: "firstName", Rule: "type", Param: "string", Message: "firstName must be string"}},
+		},
+		{
+			name: "empty",
+			body: ``,
+			want: []FieldError{{Rule: "empty", Message: "body must not be empty"}},
+		},
+		{
+			name:   "unknown field",
+			body:   `{"lastName":"Musk"}`,
+			strict: true,
+			want:   []FieldError{{Field: "lastName", Rule: "unknown", Message: "lastName is not a known field"}},
+		},
+		{
+			name: "nested type",
+			body: `{"server":{"port":"80"}}`,
+			want: []FieldError{{Field: "port", Rule: "type", Param: "number", Message: "port must be number"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d dto
+
+			dec := json.NewDecoder(strings.NewReader(tt.body))
+			if tt.strict {
+				dec.DisallowUnknownFields()
+			}
+
+			err := dec.Decode(&d)
+			assert.Error(t, err)
+
+			got := NewDecode("INVALID_DATA", &d, err)
+			assert.Equal(t, http.StatusBadRequest, got.HTTPCode)
+			assert.Equal(t, tt.want[0].Message, got.Message)
+			assert.Equal(t, tt.want, got.Extensions[FieldsExtension])
+		})
+	}
+}
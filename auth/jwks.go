@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/sync/singleflight"
+)
+
+// jwksRefreshInterval limits JWKS re-fetching when a token has an unknown key id, failed fetches included.
+const jwksRefreshInterval = time.Minute
+
+var (
+	errUnknownKey = errors.New("unknown signing key")
+	errNoKeys     = errors.New("no usable signing keys")
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwks is a set of public keys loaded from a local file or URL.
+type jwks struct {
+	source string
+	client *http.Client
+
+	// concurrent misses share one fetch.
+	flight singleflight.Group
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	attemptedAt time.Time
+}
+
+func newJWKS(ctx context.Context, source string) (*jwks, error) {
+	set := &jwks{source: source, client: &http.Client{Timeout: 10 * time.Second}}
+
+	if err := set.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// key returns the public key by its id, the set is re-fetched from URL once per interval on miss.
+func (s *jwks) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if !isURL(s.source) || !s.stale() {
+		return nil, fmt.Errorf("%w: %q", errUnknownKey, kid)
+	}
+
+	// the fetch outlives the request which started it, other requests wait for it too.
+	// A request joining after the fetch finished finds the set fresh.
+	_, err, _ := s.flight.Do("refresh", func() (any, error) {
+		if !s.stale() {
+			return nil, nil
+		}
+
+		return nil, s.refresh(context.WithoutCancel(ctx))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", errUnknownKey, kid)
+}
+
+func (s *jwks) stale() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return time.Since(s.attemptedAt) > jwksRefreshInterval
+}
+
+func (s *jwks) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.attemptedAt = time.Now()
+	s.mu.Unlock()
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return fmt.Errorf("read jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *jwks) read(ctx context.Context) ([]byte, error) {
+	if !isURL(s.source) {
+		return os.ReadFile(s.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// parseJWKS returns the signing keys of the set, keys of unsupported types or curves are skipped
+// as identity providers publish them along, the set fails only without a usable key.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var (
+		keys = make(map[string]crypto.PublicKey, len(set.Keys))
+		errs []error
+	)
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %q: %w", k.Kid, err))
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.Join(append([]error{errNoKeys}, errs...)...)
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jwk {
+	return jwk{
+		Kty: "EC",
+		Kid: kid,
+		Crv: key.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func marshalJWKS(t *testing.T, keys ...jwk) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string][]jwk{"keys": keys})
+	require.NoError(t, err)
+
+	return data
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantKids []string
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			data:     marshalJWKS(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey)),
+			wantKids: []string{"ec", "rsa"},
+		},
+		{
+			name:     "skip encryption keys",
+			data:     marshalJWKS(t, jwk{Kty: "RSA", Kid: "enc", Use: "enc"}, ecJWK("ec", &ecKey.PublicKey)),
+			wantKids: []string{"ec"},
+		},
+		{
+			name: "skip unsupported keys",
+			data: marshalJWKS(t,
+				jwk{Kty: "oct", Kid: "hmac"},
+				jwk{Kty: "OKP", Kid: "ed", Crv: "Ed25519"},
+				jwk{Kty: "EC", Kid: "k1", Crv: "secp256k1"},
+				ecJWK("ec", &ecKey.PublicKey),
+			),
+			wantKids: []string{"ec"},
+		},
+		{
+			name:    "no usable keys",
+			data:    marshalJWKS(t, jwk{Kty: "oct", Kid: "hmac"}, jwk{Kty: "RSA", Kid: "enc", Use: "enc"}),
+			wantErr: errNoKeys,
+		},
+		{
+			name:    "point is not on curve",
+			data:    marshalJWKS(t, jwk{Kty: "EC", Kid: "ec", Crv: "P-256", X: "AQ", Y: "AQ"}),
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseJWKS(tt.data)
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+
+				if tt.wantErr != assert.AnError {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			kids := make([]string, 0, len(keys))
+			for kid := range keys {
+				kids = append(kids, kid)
+			}
+
+			assert.ElementsMatch(t, tt.wantKids, kids)
+		})
+	}
+}
+
+func TestJWKS_key(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, marshalJWKS(t, ecJWK("ec", &ecKey.PublicKey)), 0o600))
+
+		set, err := newJWKS(context.Background(), path)
+		require.NoError(t, err)
+
+		key, err := set.key(context.Background(), "ec")
+		assert.NoError(t, err)
+		assert.True(t, ecKey.PublicKey.Equal(key))
+
+		_, err = set.key(context.Background(), "unknown")
+		assert.ErrorIs(t, err, errUnknownKey)
+	})
+
+	t.Run("url refresh on miss", func(t *testing.T) {
+		var (
+			calls  atomic.Int32
+			rotate atomic.Bool
+		)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+
+			keys := []jwk{ecJWK("old", &ecKey.PublicKey)}
+			if rotate.Load() {
+				keys = append(keys, ecJWK("new", &ecKey.PublicKey))
+			}
+
+			_, _ = w.Write(marshalJWKS(t, keys...))
+		}))
+		defer srv.Close()
+
+		set, err := newJWKS(context.Background(), srv.URL)
+		require.NoError(t, err)
+
+		rotate.Store(true)
+
+		// fetched recently, the miss is not re-fetched.
+		_, err = set.key(context.Background(), "new")
+		assert.ErrorIs(t, err, errUnknownKey)
+		assert.EqualValues(t, 1, calls.Load())
+
+		set.attemptedAt = time.Now().Add(-2 * jwksRefreshInterval)
+
+		_, err = set.key(context.Background(), "new")
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("concurrent misses fetch once", func(t *testing.T) {
+		var (
+			calls   atomic.Int32
+			release = make(chan struct{})
+		)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) > 1 {
+				<-release
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			_, _ = w.Write(marshalJWKS(t, ecJWK("ec", &ecKey.PublicKey)))
+		}))
+		defer srv.Close()
+
+		set, err := newJWKS(context.Background(), srv.URL)
+		require.NoError(t, err)
+
+		set.attemptedAt = time.Now().Add(-2 * jwksRefreshInterval)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, err := set.key(context.Background(), "unknown")
+				assert.Error(t, err)
+			}()
+		}
+
+		// the fetch is held until the misses pile up on it.
+		require.Eventually(t, func() bool { return calls.Load() == 2 }, 5*time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		// the failed fetch counts as well, the known key is still served.
+		_, err = set.key(context.Background(), "unknown")
+		assert.ErrorIs(t, err, errUnknownKey)
+		assert.EqualValues(t, 2, calls.Load())
+
+		key, err := set.key(context.Background(), "ec")
+		assert.NoError(t, err)
+		assert.True(t, ecKey.PublicKey.Equal(key))
+	})
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/ihippik/template-service/config"
+)
+
+// JWTAuthenticator validates HS256 tokens signed with the shared secret
+// and RS256/ES256 tokens signed with keys from JWKS.
+type JWTAuthenticator struct {
+	cfg    config.AuthCfg
+	secret []byte
+	keys   *jwks
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator creates new JWTAuthenticator, JWKS is loaded from the local file or URL.
+func NewJWTAuthenticator(ctx context.Context, cfg config.AuthCfg) (*JWTAuthenticator, error) {
+	if cfg.HMACSecret == "" && cfg.JWKS == "" {
+		return nil, errors.New("neither hmac secret nor jwks is configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Alg(),
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodES256.Alg(),
+		}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	a := &JWTAuthenticator{
+		cfg:    cfg,
+		secret: []byte(cfg.HMACSecret),
+		parser: jwt.NewParser(opts...),
+	}
+
+	if cfg.JWKS != "" {
+		keys, err := newJWKS(ctx, cfg.JWKS)
+		if err != nil {
+			return nil, err
+		}
+
+		a.keys = keys
+	}
+
+	return a, nil
+}
+
+// Scheme implements Authenticator interface.
+func (a *JWTAuthenticator) Scheme() string {
+	return "Bearer"
+}
+
+// Authenticate validates the token signature and exp, nbf, iss and aud claims.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	claims := make(jwt.MapClaims)
+
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if len(a.secret) == 0 {
+				return nil, errors.New("hmac tokens are not accepted")
+			}
+
+			return a.secret, nil
+		default:
+			if a.keys == nil {
+				return nil, errors.New("asymmetric tokens are not accepted")
+			}
+
+			kid, _ := t.Header["kid"].(string)
+
+			return a.keys.key(ctx, kid)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return nil, err
+	}
+
+	if sub == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	principal := Principal{
+		Subject: sub,
+		Method:  MethodJWT,
+		Scopes:  scopes(claims),
+	}
+
+	if raw, ok := claims[a.cfg.TenantClaim].(string); ok && raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s claim: %w", a.cfg.TenantClaim, err)
+		}
+
+		principal.TenantID = &id
+	}
+
+	if principal.TenantID == nil && !principal.HasScope(ScopeAnyTenant) {
+		return nil, fmt.Errorf("token has no %s claim", a.cfg.TenantClaim)
+	}
+
+	return &principal, nil
+}
+
+// scopes reads space-delimited "scope" claim (RFC 8693) or "scp" array.
+func scopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	var res []string
+
+	if scp, ok := claims["scp"].([]any); ok {
+		for _, s := range scp {
+			if str, ok := s.(string); ok {
+				res = append(res, str)
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ihippik/template-service/config"
+)
+
+func TestNewJWTAuthenticator(t *testing.T) {
+	_, err := NewJWTAuthenticator(context.Background(), config.AuthCfg{})
+	assert.EqualError(t, err, "neither hmac secret nor jwks is configured")
+}
+
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, marshalJWKS(t,
+		rsaJWK("rsa", &rsaKey.PublicKey),
+		ecJWK("ec", &ecKey.PublicKey),
+	), 0o600))
+
+	cfg := config.AuthCfg{
+		HMACSecret:  "secret",
+		JWKS:        path,
+		Issuer:      "https://issuer.example.org",
+		Audience:    "template-service",
+		Leeway:      time.Second,
+		TenantClaim: "tenant_id",
+	}
+
+	a, err := NewJWTAuthenticator(context.Background(), cfg)
+	require.NoError(t, err)
+
+	tenantID := uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d")
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":       "alice",
+			"iss":       cfg.Issuer,
+			"aud":       cfg.Audience,
+			"exp":       time.Now().Add(time.Hour).Unix(),
+			"tenant_id": tenantID.String(),
+		}
+	}
+
+	with := func(key string, value any) jwt.MapClaims {
+		claims := valid()
+		claims[key] = value
+
+		return claims
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+
+		str, err := token.SignedString(key)
+		require.NoError(t, err)
+
+		return str
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Principal
+		wantErr bool
+	}{
+		{
+			name:  "hs256",
+			token: sign(jwt.SigningMethodHS256, "", []byte("secret"), with("scope", "users:read users:write")),
+			want:  &Principal{Subject: "alice", Method: MethodJWT, TenantID: &tenantID, Scopes: []string{"users:read", "users:write"}},
+		},
+		{
+			name:  "rs256",
+			token: sign(jwt.SigningMethodRS256, "rsa", rsaKey, with("scp", []string{"users:read"})),
+			want:  &Principal{Subject: "alice", Method: MethodJWT, TenantID: &tenantID, Scopes: []string{"users:read"}},
+		},
+		{
+			name:  "es256",
+			token: sign(jwt.SigningMethodES256, "ec", ecKey, valid()),
+			want:  &Principal{Subject: "alice", Method: MethodJWT, TenantID: &tenantID},
+		},
+		{
+			name: "any tenant",
+			token: sign(jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+				"sub": "alice", "iss": cfg.Issuer, "aud": cfg.Audience, "exp": time.Now().Add(time.Hour).Unix(), "scope": ScopeAnyTenant,
+			}),
+			want: &Principal{Subject: "alice", Method: MethodJWT, Scopes: []string{ScopeAnyTenant}},
+		},
+		{
+			name:    "wrong hmac secret",
+			token:   sign(jwt.SigningMethodHS256, "", []byte("other"), valid()),
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			token:   sign(jwt.SigningMethodES256, "other", otherKey, valid()),
+			wantErr: true,
+		},
+		{
+			name:    "forged key id",
+			token:   sign(jwt.SigningMethodES256, "ec", otherKey, valid()),
+			wantErr: true,
+		},
+		{
+			name:    "method not allowed",
+			token:   sign(jwt.SigningMethodHS512, "", []byte("secret"), valid()),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   sign(jwt.SigningMethodHS256, "", []byte("secret"), with("exp", time.Now().Add(-time.Minute).Unix())),
+			wantErr: true,
+		},
+		{
+			name: "no expiration",
+			token: sign(jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+				"sub": "alice", "iss": cfg.Issuer, "aud": cfg.Audience,
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "not valid yet",
+			token:   sign(jwt.SigningMethodHS256, "", []byte("secret"), with("nbf", time.Now().Add(time.Minute).Unix())),
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			token:   sign(jwt.SigningMethodHS256, "", []byte("secret"), with("aud", "other")),
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			token:   sign(jwt.SigningMethodHS256, "", []byte("secret"), with("iss", "other")),
+			wantErr: true,
+		},
+		{
+			name:    "no subject",
+			token:   sign(jwt.SigningMethodHS256, "", []byte("secret"), with("sub", "")),
+			wantErr: true,
+		},
+		{
+			name:    "invalid tenant",
+			token:   sign(jwt.SigningMethodHS256, "", []byte("secret"), with("tenant_id", "invalid")),
+			wantErr: true,
+		},
+		{
+			name: "no tenant",
+			token: sign(jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+				"sub": "alice", "iss": cfg.Issuer, "aud": cfg.Audience, "exp": time.Now().Add(time.Hour).Unix(),
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   "invalid",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(context.Background(), tt.token)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// Unauthorized is the service error code.
+const Unauthorized = "UNAUTHORIZED"
+
+// Authenticator validates credentials of a single Authorization scheme.
+type Authenticator interface {
+	Scheme() string
+	Authenticate(ctx context.Context, credentials string) (*Principal, error)
+}
+
+// Middleware authenticates the request by its Authorization header
+// and puts the principal into the request context.
+func Middleware(logger *zap.Logger, realm string, authenticators ...Authenticator) func(http.Handler) http.Handler {
+	challenge := func(errDesc string) string {
+		challenges := make([]string, 0, len(authenticators))
+
+		for _, a := range authenticators {
+			c := fmt.Sprintf("%s realm=%q", a.Scheme(), realm)
+			if errDesc != "" {
+				c += fmt.Sprintf(", error=\"invalid_token\", error_description=%q", errDesc)
+			}
+
+			challenges = append(challenges, c)
+		}
+
+		return strings.Join(challenges, ", ")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+
+			if scheme == "" {
+				writeUnauthorized(w, r, logger, challenge(""), "authorization required")
+				return
+			}
+
+			for _, a := range authenticators {
+				if !strings.EqualFold(a.Scheme(), scheme) {
+					continue
+				}
+
+				principal, err := a.Authenticate(r.Context(), strings.TrimSpace(credentials))
+				if err != nil {
+					logger.Warn("authentication failed", zap.String("scheme", a.Scheme()), zap.Error(err))
+					writeUnauthorized(w, r, logger, challenge("authentication failed"), "invalid credentials")
+
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+
+				return
+			}
+
+			writeUnauthorized(w, r, logger, challenge(""), "unsupported authorization scheme")
+		})
+	}
+}
+
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, logger *zap.Logger, challenge, msg string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	apperr.Write(w, r, logger, apperr.New(http.StatusUnauthorized, Unauthorized, msg))
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type stubAuthenticator struct {
+	scheme string
+	token  string
+}
+
+func (s stubAuthenticator) Scheme() string {
+	return s.scheme
+}
+
+func (s stubAuthenticator) Authenticate(_ context.Context, credentials string) (*Principal, error) {
+	if credentials != s.token {
+		return nil, errors.New("invalid token")
+	}
+
+	return &Principal{Subject: "alice", Method: MethodJWT}, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		wantHTTPCode  int
+		wantChallenge string
+		want          []byte
+	}{
+		{
+			name:          "success",
+			authorization: "Bearer token",
+			wantHTTPCode:  http.StatusOK,
+			want:          []byte("alice"),
+		},
+		{
+			name:          "scheme is case-insensitive",
+			authorization: "bearer token",
+			wantHTTPCode:  http.StatusOK,
+			want:          []byte("alice"),
+		},
+		{
+			name:          "missing",
+			wantHTTPCode:  http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="test"`,
+			want:          []byte(`{"type":"about:blank","title":"Unauthorized","status":401,"detail":"authorization required","instance":"/v1/users","code":"UNAUTHORIZED"}`),
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer invalid",
+			wantHTTPCode:  http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="test", error="invalid_token", error_description="authentication failed"`,
+			want:          []byte(`{"type":"about:blank","title":"Unauthorized","status":401,"detail":"invalid credentials","instance":"/v1/users","code":"UNAUTHORIZED"}`),
+		},
+		{
+			name:          "unsupported scheme",
+			authorization: "Basic dXNlcjpwYXNz",
+			wantHTTPCode:  http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="test"`,
+			want:          []byte(`{"type":"about:blank","title":"Unauthorized","status":401,"detail":"unsupported authorization scheme","instance":"/v1/users","code":"UNAUTHORIZED"}`),
+		},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := FromContext(r.Context())
+		_, _ = w.Write([]byte(p.Subject))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+
+			Middleware(zap.NewNop(), "test", stubAuthenticator{scheme: "Bearer", token: "token"})(next).ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+			assert.Equal(t, tt.wantChallenge, res.Header.Get("WWW-Authenticate"))
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
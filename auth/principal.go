@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/ihippik/template-service/tenant"
+)
+
+// authentication methods.
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+)
+
+// ScopeAnyTenant lets a principal without a tenant of its own pick the tenant by the X-Tenant-ID header,
+// it's granted by the token issuer to operators serving all tenants.
+const ScopeAnyTenant = "tenants:any"
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Subject  string
+	Method   string
+	TenantID *uuid.UUID
+	Scopes   []string
+}
+
+// HasScope reports whether the principal was granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type ctxKey struct{}
+
+// WithPrincipal returns a copy of the context carrying the principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the principal stored in the context.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*Principal)
+	return p, ok
+}
+
+// TenantFromPrincipal resolves the tenant of the authenticated principal, it is a tenant.Resolver.
+// The header is taken only from principals holding ScopeAnyTenant, never in place of their own tenant.
+func TenantFromPrincipal(r *http.Request) (uuid.UUID, bool, error) {
+	p, ok := FromContext(r.Context())
+	if !ok {
+		return uuid.Nil, false, nil
+	}
+
+	if p.TenantID != nil {
+		return *p.TenantID, true, nil
+	}
+
+	if p.HasScope(ScopeAnyTenant) {
+		return tenant.FromHeader(r)
+	}
+
+	return uuid.Nil, false, nil
+}
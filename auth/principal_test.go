@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/tenant"
+)
+
+func TestTenantFromPrincipal(t *testing.T) {
+	own := uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d")
+	other := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		header    string
+		want      uuid.UUID
+		wantOK    bool
+		wantErr   bool
+	}{
+		{
+			name:      "own tenant",
+			principal: &Principal{Subject: "alice", TenantID: &own},
+			want:      own,
+			wantOK:    true,
+		},
+		{
+			name:      "header ignored with own tenant",
+			principal: &Principal{Subject: "alice", TenantID: &own, Scopes: []string{ScopeAnyTenant}},
+			header:    other.String(),
+			want:      own,
+			wantOK:    true,
+		},
+		{
+			name:      "header without any tenant scope",
+			principal: &Principal{Subject: "alice"},
+			header:    other.String(),
+		},
+		{
+			name:      "any tenant",
+			principal: &Principal{Subject: "alice", Scopes: []string{ScopeAnyTenant}},
+			header:    other.String(),
+			want:      other,
+			wantOK:    true,
+		},
+		{
+			name:      "any tenant invalid header",
+			principal: &Principal{Subject: "alice", Scopes: []string{ScopeAnyTenant}},
+			header:    "invalid",
+			wantErr:   true,
+		},
+		{
+			name:   "anonymous",
+			header: other.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), tt.principal))
+			}
+
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+
+			got, ok, err := TenantFromPrincipal(req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
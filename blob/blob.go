@@ -0,0 +1,25 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when the object does not exist in the storage.
+var ErrNotFound = errors.New("object not found")
+
+// Storage represent binary object storage.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is a stored binary object, the caller must close it.
+type Object struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
@@ -0,0 +1,73 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+
+	storage, err := NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	err = storage.Put(ctx, "avatars/1/64.png", bytes.NewReader([]byte("image")))
+	assert.NoError(t, err)
+
+	obj, err := storage.Get(ctx, "avatars/1/64.png")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), obj.Size)
+
+	data, err := io.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("image"), data)
+	assert.NoError(t, obj.Close())
+
+	assert.NoError(t, storage.Delete(ctx, "avatars/1/64.png"))
+	assert.NoError(t, storage.Delete(ctx, "avatars/1/64.png"))
+
+	_, err = storage.Get(ctx, "avatars/1/64.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocal_path(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{
+			name:    "nested key",
+			key:     "avatars/1/64.png",
+			wantErr: nil,
+		},
+		{
+			name:    "parent dir",
+			key:     "../etc/passwd",
+			wantErr: errors.New(`invalid key "../etc/passwd"`),
+		},
+		{
+			name:    "absolute path",
+			key:     "/etc/passwd",
+			wantErr: errors.New(`invalid key "/etc/passwd"`),
+		},
+	}
+
+	storage := &Local{dir: "/data"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := storage.path(tt.key)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,15 +2,31 @@ package config
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/sethvargo/go-envconfig"
 )
 
 type (
 	Config struct {
-		ServerAddr string `env:"SERVER_ADDR,required"`
-		DB         DBCfg  `env:",prefix=DB_"`
-		Log        LogCfg `env:",prefix=LOG_"`
+		ServerAddr string       `env:"SERVER_ADDR,required"`
+		AdminAddr  string       `env:"ADMIN_ADDR,default=:9090"`
+		AdminToken string       `env:"ADMIN_TOKEN" secret:"true"`
+		DB         DBCfg        `env:",prefix=DB_"`
+		Log        LogCfg       `env:",prefix=LOG_"`
+		Avatar     AvatarCfg    `env:",prefix=AVATAR_"`
+		Auth       AuthCfg      `env:",prefix=AUTH_"`
+		Mask       MaskCfg      `env:",prefix=MASK_"`
+		Policy     PolicyCfg    `env:",prefix=POLICY_"`
+		RateLimit  RateLimitCfg `env:",prefix=RATE_LIMIT_"`
+		Error      ErrorCfg     `env:",prefix=ERROR_"`
+		Tracing    TracingCfg   `env:",prefix=TRACING_"`
+		Health     HealthCfg    `env:",prefix=HEALTH_"`
+		TLS        TLSCfg       `env:",prefix=TLS_"`
+		Socket     SocketCfg    `env:",prefix=SOCKET_"`
+		Upgrade    UpgradeCfg   `env:",prefix=UPGRADE_"`
+		Shutdown   ShutdownCfg  `env:",prefix=SHUTDOWN_"`
 	}
 
 	LogCfg struct {
@@ -20,18 +36,143 @@ type (
 	}
 
 	DBCfg struct {
-		Conn         string `env:"CONN,required"`
+		Conn         string `env:"CONN,required" secret:"true"`
 		MaxOpenConns int    `env:"MAX_OPEN_CONNS, default=10"`
 		MaxIdleConns int    `env:"MAX_IDLE_CONNS, default=10"`
 	}
+
+	AvatarCfg struct {
+		Dir     string `env:"DIR,default=./data/avatars"`
+		MaxSize int64  `env:"MAX_SIZE,default=5242880"`
+	}
+
+	// MaskCfg holds the field masking policy as JSON, keyed by role or scope, "*" is the default:
+	// {"*":{"birthday":"omit"},"viewer":{"birthday":"redact"},"admin":{}}.
+	MaskCfg struct {
+		Policy string `env:"POLICY"`
+	}
+
+	PolicyCfg struct {
+		File           string        `env:"FILE"`
+		ReloadInterval time.Duration `env:"RELOAD_INTERVAL,default=5s"`
+	}
+
+	// RateLimitCfg limits are "<requests>/<period>", routes are keyed by the mux pattern:
+	// RATE_LIMIT_ROUTES="GET /v1/users:600/1m,POST /v1/users:60/1m".
+	// IP limits every client address before authentication, it is disabled when empty.
+	// TRUST_PROXY takes the address from the last X-Forwarded-For entry, appended by the proxy in front.
+	RateLimitCfg struct {
+		Default    string            `env:"DEFAULT,default=300/1m"`
+		Routes     map[string]string `env:"ROUTES"`
+		IP         string            `env:"IP,default=1200/1m"`
+		TrustProxy bool              `env:"TRUST_PROXY"`
+	}
+
+	// ErrorCfg selects the error body: "problem" for RFC 7807 or "legacy" for {"code","message"},
+	// problem types are "<TYPE_BASE>/<code>" or "about:blank" when the base is empty.
+	ErrorCfg struct {
+		Format   string `env:"FORMAT,default=problem"`
+		TypeBase string `env:"TYPE_BASE"`
+	}
+
+	// TracingCfg exporter is one of "none", "otlp" (OTLP/HTTP to ENDPOINT), "stdout" or "file" (JSON to FILE).
+	TracingCfg struct {
+		Exporter    string  `env:"EXPORTER,default=none"`
+		Endpoint    string  `env:"ENDPOINT,default=localhost:4318"`
+		Insecure    bool    `env:"INSECURE,default=true"`
+		File        string  `env:"FILE,default=./traces.json"`
+		SampleRatio float64 `env:"SAMPLE_RATIO,default=1"`
+		ServiceName string  `env:"SERVICE_NAME,default=template-service"`
+	}
+
+	// HealthCfg timeout bounds readiness checks, the service reports not ready for DRAIN_DELAY
+	// before the server shuts down.
+	HealthCfg struct {
+		Timeout    time.Duration `env:"TIMEOUT,default=2s"`
+		DrainDelay time.Duration `env:"DRAIN_DELAY,default=5s"`
+	}
+
+	// TLSCfg enables HTTPS when CERT_FILE is set, CLIENT_AUTH is "none", "request" or "require"
+	// (verified against CLIENT_CA_FILE), CIPHERS is "default", "modern" or "compat".
+	// Files are reloaded when they change on disk.
+	TLSCfg struct {
+		CertFile       string        `env:"CERT_FILE"`
+		KeyFile        string        `env:"KEY_FILE"`
+		ClientCAFile   string        `env:"CLIENT_CA_FILE"`
+		ClientAuth     string        `env:"CLIENT_AUTH,default=none"`
+		MinVersion     string        `env:"MIN_VERSION,default=1.2"`
+		Ciphers        string        `env:"CIPHERS,default=default"`
+		ReloadInterval time.Duration `env:"RELOAD_INTERVAL,default=10s"`
+	}
+
+	// SocketCfg adds a Unix domain socket listener at PATH served in plain HTTP for a local proxy,
+	// MODE is the octal permissions of the socket file.
+	SocketCfg struct {
+		Path string `env:"PATH"`
+		Mode string `env:"MODE,default=0660"`
+	}
+
+	// UpgradeCfg timeout bounds the start of the new binary taking over the listeners on SIGUSR2.
+	UpgradeCfg struct {
+		Timeout time.Duration `env:"TIMEOUT,default=30s"`
+	}
+
+	// ShutdownCfg timeout bounds the stop of every component, TIMEOUTS override it by the component name:
+	// SHUTDOWN_TIMEOUTS="http:30s,db:5s". Components are drain, http, admin, tls, reload, ratelimit,
+	// policy, db and tracing.
+	ShutdownCfg struct {
+		Timeout  time.Duration            `env:"TIMEOUT,default=15s"`
+		Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+	}
+
+	AuthCfg struct {
+		Realm       string        `env:"REALM,default=template-service"`
+		HMACSecret  string        `env:"HMAC_SECRET" secret:"true"`
+		JWKS        string        `env:"JWKS"`
+		Issuer      string        `env:"ISSUER"`
+		Audience    string        `env:"AUDIENCE"`
+		Leeway      time.Duration `env:"LEEWAY,default=30s"`
+		TenantClaim string        `env:"TENANT_CLAIM,default=tenant_id"`
+	}
 )
 
-func New(ctx context.Context) (*Config, error) {
+// Sources are the configuration layers, each overrides the previous one: file, environment, flags.
+type Sources struct {
+	// File is a YAML or TOML file, the format is chosen by the extension.
+	File string
+	// Flags are command line values keyed by the environment variable names.
+	Flags map[string]string
+}
+
+// New reads the configuration from the sources and validates it.
+func New(ctx context.Context, src Sources) (*Config, error) {
 	var cfg Config
 
-	if err := envconfig.Process(ctx, &cfg); err != nil {
+	fileValues, err := readFile(src.File)
+	if err != nil {
 		return nil, err
 	}
 
+	// every setting may be given as <KEY>_FILE, secrets also as a reference like file:// or env://.
+	lookuper := newLayerLookuper(ctx,
+		envconfig.MapLookuper(src.Flags),
+		envconfig.OsLookuper(),
+		envconfig.MapLookuper(fileValues),
+	)
+
+	err = envconfig.ProcessWith(ctx, &cfg, lookuper)
+
+	if lookuper.err != nil {
+		return nil, lookuper.err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
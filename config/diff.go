@@ -0,0 +1,36 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Change is the setting differing between two configurations, secret values are redacted.
+type Change struct {
+	Key string
+	Old any
+	New any
+}
+
+// Diff lists the settings changed from old to new by their file keys: db.max_open_conns.
+func Diff(old, new *Config) []Change {
+	var changes []Change
+
+	oldVal, newVal := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+
+	for _, f := range fields() {
+		o, n := oldVal.FieldByIndex(f.index), newVal.FieldByIndex(f.index)
+
+		if reflect.DeepEqual(o.Interface(), n.Interface()) {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Key: strings.Join(f.path, "."),
+			Old: printValue(o, f.secret),
+			New: printValue(n, f.secret),
+		})
+	}
+
+	return changes
+}
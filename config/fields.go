@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// field is a leaf setting of Config: DB_MAX_OPEN_CONNS is db.max_open_conns in the file.
+type field struct {
+	env    string
+	path   []string
+	index  []int
+	secret bool
+}
+
+// fields lists the leaf settings of Config by their env tags.
+func fields() []field {
+	return collect(reflect.TypeOf(Config{}), "", nil, nil)
+}
+
+func collect(t reflect.Type, prefix string, path []string, index []int) []field {
+	var out []field
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag, ok := f.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(append([]int(nil), index...), i)
+
+		if name == "" && f.Type.Kind() == reflect.Struct {
+			section := envOption(opts, "prefix")
+			sectionPath := append(append([]string(nil), path...), fileKey(section))
+
+			out = append(out, collect(f.Type, prefix+section, sectionPath, idx)...)
+
+			continue
+		}
+
+		out = append(out, field{
+			env:    prefix + name,
+			path:   append(append([]string(nil), path...), fileKey(name)),
+			index:  idx,
+			secret: f.Tag.Get("secret") == "true",
+		})
+	}
+
+	return out
+}
+
+// envOption returns the value of the option of the env tag: prefix=DB_ -> DB_.
+func envOption(opts, name string) string {
+	for _, opt := range strings.Split(opts, ",") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(opt), name+"="); ok {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// fileKey converts the env name to the file key: RATE_LIMIT_ -> rate_limit.
+func fileKey(env string) string {
+	return strings.ToLower(strings.TrimSuffix(env, "_"))
+}
+
+// IsSetting reports whether the key names a setting by its environment variable, <KEY>_FILE included.
+func IsSetting(key string) bool {
+	for _, f := range fields() {
+		if key == f.env || key == f.env+fileSuffix {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads the YAML or TOML config file, values are keyed by the environment variable names,
+// so the file is one more layer of the same settings.
+func readFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	tree := make(map[string]any)
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parse config file: %w", err)
+	}
+
+	return flatten(tree)
+}
+
+// flatten maps the file tree to the env values of the fields, unknown keys are rejected.
+func flatten(tree map[string]any) (map[string]string, error) {
+	var (
+		values   = make(map[string]string)
+		known    = make(map[string]struct{})
+		sections = make(map[string]struct{})
+		errs     []error
+	)
+
+	cfgType := reflect.TypeOf(Config{})
+
+	for _, f := range fields() {
+		key := strings.Join(f.path, ".")
+		known[key] = struct{}{}
+
+		for i := 1; i < len(f.path); i++ {
+			sections[strings.Join(f.path[:i], ".")] = struct{}{}
+		}
+
+		v, ok := lookup(tree, f.path)
+		if !ok {
+			continue
+		}
+
+		s, err := envValue(v, cfgType.FieldByIndex(f.index).Type.Kind())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+
+		values[f.env] = s
+	}
+
+	errs = append(errs, unknownKeys(tree, "", known, sections)...)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("config file: %w", errors.Join(errs...))
+	}
+
+	return values, nil
+}
+
+func lookup(tree map[string]any, path []string) (any, bool) {
+	var v any = tree
+
+	for _, key := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func unknownKeys(tree map[string]any, prefix string, known, sections map[string]struct{}) []error {
+	var errs []error
+
+	keys := make([]string, 0, len(tree))
+
+	for k := range tree {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := prefix + k
+
+		if _, ok := known[key]; ok {
+			continue
+		}
+
+		if sub, ok := tree[k].(map[string]any); ok {
+			if _, ok := sections[key]; ok {
+				errs = append(errs, unknownKeys(sub, key+".", known, sections)...)
+				continue
+			}
+		}
+
+		errs = append(errs, fmt.Errorf("unknown key %s", key))
+	}
+
+	return errs
+}
+
+// envValue formats the file value as the env value: maps are "k:v,k:v", lists are "a,b".
+func envValue(v any, kind reflect.Kind) (string, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		if kind != reflect.Map {
+			return "", errors.New("must not be a table")
+		}
+
+		keys := make([]string, 0, len(val))
+
+		for k := range val {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+
+		for _, k := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s:%v", k, val[k]))
+		}
+
+		return strings.Join(pairs, ","), nil
+	case []any:
+		if kind != reflect.Slice {
+			return "", errors.New("must not be a list")
+		}
+
+		items := make([]string, 0, len(val))
+
+		for _, item := range val {
+			items = append(items, fmt.Sprint(item))
+		}
+
+		return strings.Join(items, ","), nil
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// redacted replaces values of secret settings.
+const redacted = "[REDACTED]"
+
+// Redacted returns the settings as the tree of the config file with secrets redacted.
+func (c *Config) Redacted() map[string]any {
+	tree := make(map[string]any)
+	val := reflect.ValueOf(c).Elem()
+
+	for _, f := range fields() {
+		node := tree
+
+		for _, key := range f.path[:len(f.path)-1] {
+			sub, ok := node[key].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				node[key] = sub
+			}
+
+			node = sub
+		}
+
+		node[f.path[len(f.path)-1]] = printValue(val.FieldByIndex(f.index), f.secret)
+	}
+
+	return tree
+}
+
+func printValue(v reflect.Value, secret bool) any {
+	if secret {
+		if v.IsZero() {
+			return ""
+		}
+
+		return redacted
+	}
+
+	// durations are printed as in the config: 5s.
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sethvargo/go-envconfig"
+)
+
+// fileSuffix marks the variable holding the path of the file with the value: DB_CONN_FILE.
+const fileSuffix = "_FILE"
+
+// Resolver returns the secret the reference points to, the reference is given without the scheme.
+type Resolver func(ctx context.Context, ref string) (string, error)
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{
+		"file": resolveFile,
+		"env":  resolveEnv,
+	}
+)
+
+// RegisterResolver adds the resolver of "<scheme>://" references, values of other schemes are taken as is.
+func RegisterResolver(scheme string, r Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+
+	resolvers[scheme] = r
+}
+
+// resolveRef replaces the value referencing a secret with the secret: file:///run/secrets/db -> the file content.
+func resolveRef(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+
+	resolversMu.RLock()
+	r, ok := resolvers[scheme]
+	resolversMu.RUnlock()
+
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := r(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s reference: %w", scheme, err)
+	}
+
+	return secret, nil
+}
+
+func resolveFile(_ context.Context, path string) (string, error) {
+	return readSecret(path)
+}
+
+func resolveEnv(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+
+	return value, nil
+}
+
+// readSecret reads the secret file, the trailing newline is dropped as mounted secrets often have one.
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// layerLookuper looks the key up layer by layer, in every layer the key itself comes before
+// the <KEY>_FILE variable holding the path of the file with the value. Values of secret settings
+// may reference the secret: file:// or env://.
+type layerLookuper struct {
+	ctx     context.Context
+	layers  []envconfig.Lookuper
+	secrets map[string]bool
+	err     error
+}
+
+func newLayerLookuper(ctx context.Context, layers ...envconfig.Lookuper) *layerLookuper {
+	secrets := make(map[string]bool)
+
+	for _, f := range fields() {
+		if f.secret {
+			secrets[f.env] = true
+		}
+	}
+
+	return &layerLookuper{ctx: ctx, layers: layers, secrets: secrets}
+}
+
+// Lookup implements envconfig.Lookuper interface.
+func (l *layerLookuper) Lookup(key string) (string, bool) {
+	for _, layer := range l.layers {
+		if value, ok := layer.Lookup(key); ok {
+			return l.resolve(key, value)
+		}
+
+		if path, ok := layer.Lookup(key + fileSuffix); ok {
+			value, err := readSecret(path)
+			if err != nil {
+				l.err = errors.Join(l.err, fmt.Errorf("%s: %w", key+fileSuffix, err))
+				return "", false
+			}
+
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func (l *layerLookuper) resolve(key, value string) (string, bool) {
+	if !l.secrets[key] {
+		return value, true
+	}
+
+	secret, err := resolveRef(l.ctx, value)
+	if err != nil {
+		l.err = errors.Join(l.err, fmt.Errorf("%s: %w", key, err))
+		return "", false
+	}
+
+	return secret, true
+}
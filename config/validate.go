@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Validate checks the settings depending on each other or limited to a set of values,
+// the problems are reported by the environment variable names.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if !oneOf(c.Log.Level, "debug", "info", "warn", "error") {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL %q must be one of debug, info, warn, error", c.Log.Level))
+	}
+
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative"))
+	}
+
+	// zero open connections is unlimited.
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs = append(errs, fmt.Errorf(
+			"DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.DB.MaxIdleConns, c.DB.MaxOpenConns,
+		))
+	}
+
+	if c.ServerAddr == c.AdminAddr {
+		errs = append(errs, fmt.Errorf("ADMIN_ADDR %q must differ from SERVER_ADDR", c.AdminAddr))
+	}
+
+	if c.Upgrade.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("UPGRADE_TIMEOUT (%s) must be positive", c.Upgrade.Timeout))
+	}
+
+	if c.Avatar.MaxSize <= 0 {
+		errs = append(errs, fmt.Errorf("AVATAR_MAX_SIZE (%d) must be positive", c.Avatar.MaxSize))
+	}
+
+	if c.Policy.File != "" && c.Policy.ReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf("POLICY_RELOAD_INTERVAL (%s) must be positive", c.Policy.ReloadInterval))
+	}
+
+	if !oneOf(c.Error.Format, "problem", "legacy") {
+		errs = append(errs, fmt.Errorf("ERROR_FORMAT %q must be one of problem, legacy", c.Error.Format))
+	}
+
+	if !oneOf(c.Tracing.Exporter, "none", "otlp", "stdout", "file") {
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER %q must be one of none, otlp, stdout, file", c.Tracing.Exporter))
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO (%g) must be within [0, 1]", c.Tracing.SampleRatio))
+	}
+
+	if c.Health.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("HEALTH_TIMEOUT (%s) must be positive", c.Health.Timeout))
+	}
+
+	if c.Health.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("HEALTH_DRAIN_DELAY (%s) must not be negative", c.Health.DrainDelay))
+	}
+
+	errs = append(errs, c.TLS.validate()...)
+
+	if c.Shutdown.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUT (%s) must be positive", c.Shutdown.Timeout))
+	}
+
+	for name, t := range c.Shutdown.Timeouts {
+		if t <= 0 {
+			errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUTS of %s (%s) must be positive", name, t))
+		}
+	}
+
+	drainTimeout := c.Shutdown.Timeout
+	if t, ok := c.Shutdown.Timeouts["drain"]; ok {
+		drainTimeout = t
+	}
+
+	if drainTimeout <= c.Health.DrainDelay {
+		errs = append(errs, fmt.Errorf(
+			"shutdown timeout of drain (%s) must exceed HEALTH_DRAIN_DELAY (%s)", drainTimeout, c.Health.DrainDelay,
+		))
+	}
+
+	if _, err := strconv.ParseUint(c.Socket.Mode, 8, 32); err != nil {
+		errs = append(errs, fmt.Errorf("SOCKET_MODE %q must be octal permissions", c.Socket.Mode))
+	}
+
+	// the socket serves plain HTTP to the local proxy, so it can't require client certificates.
+	if c.Socket.Path != "" && c.TLS.ClientAuth == "require" {
+		errs = append(errs, errors.New("SOCKET_PATH serves plain HTTP and can't be used with TLS_CLIENT_AUTH \"require\""))
+	}
+
+	return errors.Join(errs...)
+}
+
+func oneOf(v string, values ...string) bool {
+	for _, s := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c TLSCfg) validate() []error {
+	var errs []error
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+
+	if !oneOf(c.ClientAuth, "none", "request", "require") {
+		errs = append(errs, fmt.Errorf("TLS_CLIENT_AUTH %q must be one of none, request, require", c.ClientAuth))
+	}
+
+	if c.ClientAuth != "none" && c.CertFile == "" {
+		errs = append(errs, fmt.Errorf("TLS_CLIENT_AUTH %q requires TLS_CERT_FILE", c.ClientAuth))
+	}
+
+	if c.ClientAuth == "require" && c.ClientCAFile == "" {
+		errs = append(errs, errors.New("TLS_CLIENT_AUTH \"require\" requires TLS_CLIENT_CA_FILE"))
+	}
+
+	if !oneOf(c.MinVersion, "1.2", "1.3") {
+		errs = append(errs, fmt.Errorf("TLS_MIN_VERSION %q must be one of 1.2, 1.3", c.MinVersion))
+	}
+
+	if !oneOf(c.Ciphers, "default", "modern", "compat") {
+		errs = append(errs, fmt.Errorf("TLS_CIPHERS %q must be one of default, modern, compat", c.Ciphers))
+	}
+
+	if c.CertFile != "" && c.ReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf("TLS_RELOAD_INTERVAL (%s) must be positive", c.ReloadInterval))
+	}
+
+	return errs
+}
@@ -0,0 +1,171 @@
+// Package fieldmask hides fields of JSON payloads from callers that are not allowed to see them.
+package fieldmask
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goccy/go-json"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/rbac"
+)
+
+// Action is applied to a masked field.
+type Action string
+
+// masking actions, omit is the more restrictive one.
+const (
+	Redact Action = "redact"
+	Omit   Action = "omit"
+)
+
+// Default is the policy key applied to callers matching no other key.
+const Default = "*"
+
+// Rules maps JSON field names to the action.
+type Rules map[string]Action
+
+// Policy maps a role or a scope to the masking rules.
+type Policy map[string]Rules
+
+// Parse parses the JSON policy, an empty string means no masking.
+func Parse(raw string) (Policy, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var policy Policy
+
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	for grant, rules := range policy {
+		for field, action := range rules {
+			if action != Redact && action != Omit {
+				return nil, fmt.Errorf("%s: field %q: unknown action %q", grant, field, action)
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+// Grants returns roles and scopes of the caller, they are the policy keys.
+func Grants(ctx context.Context) []string {
+	grants, _ := rbac.RolesFromContext(ctx)
+
+	if p, ok := auth.FromContext(ctx); ok {
+		grants = append(grants[:len(grants):len(grants)], p.Scopes...)
+	}
+
+	return grants
+}
+
+// Effective returns rules for the caller with the grants.
+// A caller matching several keys sees a field unless all of them mask it,
+// the least restrictive action wins; callers matching no key get the default rules.
+func (p Policy) Effective(grants []string) Rules {
+	var (
+		res     Rules
+		matched bool
+	)
+
+	for _, grant := range grants {
+		rules, ok := p[grant]
+		if !ok {
+			continue
+		}
+
+		if !matched {
+			res = make(Rules, len(rules))
+			for field, action := range rules {
+				res[field] = action
+			}
+
+			matched = true
+
+			continue
+		}
+
+		for field, action := range res {
+			other, ok := rules[field]
+
+			switch {
+			case !ok:
+				delete(res, field)
+			case other == Redact && action == Omit:
+				res[field] = Redact
+			}
+		}
+	}
+
+	if !matched {
+		return p[Default]
+	}
+
+	return res
+}
+
+// ForContext returns rules for the caller from the context.
+func (p Policy) ForContext(ctx context.Context) Rules {
+	if len(p) == 0 {
+		return nil
+	}
+
+	return p.Effective(Grants(ctx))
+}
+
+// Apply masks fields of the single record.
+func (r Rules) Apply(record map[string]json.RawMessage) {
+	for field, action := range r {
+		if _, ok := record[field]; !ok {
+			continue
+		}
+
+		switch action {
+		case Omit:
+			delete(record, field)
+		case Redact:
+			record[field] = json.RawMessage("null")
+		}
+	}
+}
+
+// MaskData masks every record of the {"data":[...]} response envelope.
+func (r Rules) MaskData(data []byte) ([]byte, error) {
+	if len(r) == 0 {
+		return data, nil
+	}
+
+	var envelope map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+
+	raw, ok := envelope["data"]
+	if !ok {
+		return data, nil
+	}
+
+	var records []map[string]json.RawMessage
+
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		r.Apply(record)
+	}
+
+	masked, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope["data"] = masked
+
+	return json.Marshal(envelope)
+}
@@ -0,0 +1,137 @@
+package fieldmask
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/rbac"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Policy
+		wantErr string
+	}{
+		{
+			name: "empty",
+			raw:  "",
+			want: nil,
+		},
+		{
+			name: "success",
+			raw:  `{"*":{"birthday":"omit"},"viewer":{"birthday":"redact"},"admin":{}}`,
+			want: Policy{
+				"*":      {"birthday": Omit},
+				"viewer": {"birthday": Redact},
+				"admin":  {},
+			},
+		},
+		{
+			name:    "unknown action",
+			raw:     `{"viewer":{"birthday":"hide"}}`,
+			wantErr: `viewer: field "birthday": unknown action "hide"`,
+		},
+		{
+			name:    "invalid json",
+			raw:     `[`,
+			wantErr: "unmarshal: expected { character for map value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.raw)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPolicy_Effective(t *testing.T) {
+	policy := Policy{
+		Default:  {"birthday": Omit, "labels": Omit},
+		"viewer": {"birthday": Omit, "labels": Redact},
+		"editor": {"birthday": Redact},
+		"admin":  {},
+	}
+
+	tests := []struct {
+		name   string
+		grants []string
+		want   Rules
+	}{
+		{
+			name:   "default",
+			grants: []string{"unknown"},
+			want:   Rules{"birthday": Omit, "labels": Omit},
+		},
+		{
+			name:   "single",
+			grants: []string{"viewer"},
+			want:   Rules{"birthday": Omit, "labels": Redact},
+		},
+		{
+			name:   "least restrictive wins",
+			grants: []string{"viewer", "editor"},
+			want:   Rules{"birthday": Redact},
+		},
+		{
+			name:   "unmasked",
+			grants: []string{"viewer", "admin"},
+			want:   Rules{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Effective(tt.grants))
+		})
+	}
+}
+
+func TestGrants(t *testing.T) {
+	ctx := rbac.WithRoles(context.Background(), []string{"viewer"})
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: "alice", Scopes: []string{"users:read"}})
+
+	assert.Equal(t, []string{"viewer", "users:read"}, Grants(ctx))
+	assert.Empty(t, Grants(context.Background()))
+}
+
+func TestRules_MaskData(t *testing.T) {
+	rules := Rules{"birthday": Redact, "labels": Omit}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "list",
+			data: `{"data":[{"id":"1","birthday":"1971-06-28","labels":{"team":"core"}},{"id":"2"}]}`,
+			want: `{"data":[{"birthday":null,"id":"1"},{"id":"2"}]}`,
+		},
+		{
+			name: "empty",
+			data: `{}`,
+			want: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rules.MaskData([]byte(tt.data))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
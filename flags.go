@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ihippik/template-service/config"
+)
+
+// settingFlags override the settings of the same environment variables.
+var settingFlags = []struct {
+	name  string
+	env   string
+	usage string
+}{
+	{name: "server-addr", env: "SERVER_ADDR", usage: "public API address"},
+	{name: "admin-addr", env: "ADMIN_ADDR", usage: "admin address"},
+	{name: "log-level", env: "LOG_LEVEL", usage: "log level: debug, info, warn or error"},
+	{name: "db-conn", env: "DB_CONN", usage: "db connection"},
+}
+
+// configFlags are the flags of commands reading the configuration.
+func configFlags() []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "YAML or TOML config file",
+			EnvVars: []string{"CONFIG_FILE"},
+		},
+		&cli.GenericFlag{
+			Name:  "set",
+			Value: &overrides{},
+			Usage: "override any setting by its environment variable name: --set RATE_LIMIT_DEFAULT=100/1m",
+		},
+	}
+
+	for _, f := range settingFlags {
+		flags = append(flags, &cli.StringFlag{Name: f.name, Usage: f.usage})
+	}
+
+	return flags
+}
+
+// configSources collects the config file and the flags set on the command line.
+func configSources(c *cli.Context) (config.Sources, error) {
+	src := config.Sources{File: c.String("config"), Flags: make(map[string]string)}
+
+	if set, ok := c.Generic("set").(*overrides); ok {
+		for _, kv := range *set {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok || key == "" {
+				return src, fmt.Errorf("invalid --set %q, expected KEY=VALUE", kv)
+			}
+
+			key = strings.ToUpper(key)
+			if !config.IsSetting(key) {
+				return src, fmt.Errorf("invalid --set %q, unknown setting %s", kv, key)
+			}
+
+			src.Flags[key] = value
+		}
+	}
+
+	for _, f := range settingFlags {
+		if c.IsSet(f.name) {
+			src.Flags[f.env] = c.String(f.name)
+		}
+	}
+
+	return src, nil
+}
+
+// overrides collects the --set values as given, unlike cli.StringSliceFlag it doesn't split them by comma.
+type overrides []string
+
+// Set implements cli.Generic interface.
+func (o *overrides) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// String implements cli.Generic interface.
+func (o *overrides) String() string {
+	return strings.Join(*o, " ")
+}
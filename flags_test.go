@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ihippik/template-service/config"
+)
+
+func TestConfigSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "values with commas",
+			args: []string{
+				"--set", "RATE_LIMIT_ROUTES=GET /v1/users:600/1m,POST /v1/users:60/1m",
+				"--set", "shutdown_timeouts=http:30s,db:5s",
+				"--set", "DB_CONN=postgres://localhost/db?options=a,b",
+			},
+			want: map[string]string{
+				"RATE_LIMIT_ROUTES": "GET /v1/users:600/1m,POST /v1/users:60/1m",
+				"SHUTDOWN_TIMEOUTS": "http:30s,db:5s",
+				"DB_CONN":           "postgres://localhost/db?options=a,b",
+			},
+		},
+		{
+			name: "named flag wins",
+			args: []string{"--set", "LOG_LEVEL=debug", "--log-level", "warn", "--set", "DB_CONN_FILE=/run/secrets/db"},
+			want: map[string]string{"LOG_LEVEL": "warn", "DB_CONN_FILE": "/run/secrets/db"},
+		},
+		{
+			name:    "unknown setting",
+			args:    []string{"--set", "RATE_LIMIT_DEFUALT=100/1m"},
+			wantErr: "unknown setting RATE_LIMIT_DEFUALT",
+		},
+		{
+			name:    "no value",
+			args:    []string{"--set", "LOG_LEVEL"},
+			wantErr: "expected KEY=VALUE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				src config.Sources
+				err error
+			)
+
+			app := &cli.App{
+				Flags: configFlags(),
+				Action: func(c *cli.Context) error {
+					src, err = configSources(c)
+					return nil
+				},
+			}
+
+			require.NoError(t, app.Run(append([]string{"app"}, tt.args...)))
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, src.Flags)
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package group
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+type service interface {
+	GetGroup(ctx context.Context, id uuid.UUID) (*Group, error)
+	ListGroups(ctx context.Context) ([]*Group, error)
+	ListUserGroups(ctx context.Context, userID uuid.UUID) ([]*Group, error)
+	UpdateGroup(ctx context.Context, id uuid.UUID, dto DTO) (*Group, error)
+	CreateGroup(ctx context.Context, dto DTO) (*Group, error)
+	DeleteGroup(ctx context.Context, id uuid.UUID) error
+	AddMember(ctx context.Context, groupID, userID uuid.UUID) error
+	RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error
+}
+
+type Endpoint struct {
+	logger *zap.Logger
+	svc    service
+}
+
+func NewEndpoint(logger *zap.Logger, svc service) *Endpoint {
+	return &Endpoint{logger: logger, svc: svc}
+}
+
+type response struct {
+	Data []*Group `json:"data,omitempty"`
+}
+
+// ListGroups http list groups handler.
+// @Title List
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description list groups
+// @Summary fetch groups
+// @Success 200 {object} response
+// @Failure 500 {object} apperr.Problem
+// @Router /v1/groups [GET]
+func (e *Endpoint) ListGroups(w http.ResponseWriter, r *http.Request) {
+	var resp response
+
+	w.Header().Set("Content-Type", "application/json")
+
+	models, err := e.svc.ListGroups(r.Context())
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = models
+	e.writeResp(w, r, resp)
+}
+
+// ListUserGroups http list groups of the user handler.
+// @Title ListByUser
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description list groups the user belongs to, including parents of her groups
+// @Summary fetch user groups
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 500 {object} apperr.Problem
+// @Param id path string true "User ID"
+// @Router /v1/users/{id}/groups [GET]
+func (e *Endpoint) ListUserGroups(w http.ResponseWriter, r *http.Request) {
+	var resp response
+
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		e.logger.Warn("could not parse user id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
+
+		return
+	}
+
+	models, err := e.svc.ListUserGroups(r.Context(), userID)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = models
+	e.writeResp(w, r, resp)
+}
+
+// CreateGroup http create group handler.
+// @Title Create
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description create group
+// @Summary create group
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param model body DTO true "New model"
+// @Router /v1/groups [POST]
+func (e *Endpoint) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var (
+		dto  DTO
+		resp response
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		e.logger.Warn("decode group data", zap.Error(err))
+		e.writeErr(w, r, newDecodeErr(InvalidGroupData, &dto, err))
+
+		return
+	}
+
+	model, err := e.svc.CreateGroup(r.Context(), dto)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = append(resp.Data, model)
+
+	w.WriteHeader(http.StatusCreated)
+	e.writeResp(w, r, resp)
+}
+
+// UpdateGroup http update group handler.
+// @Title Update
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description update group by id
+// @Summary update group
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param gid path string true "Group ID"
+// @Param model body DTO true "New model"
+// @Router /v1/groups/{gid} [PUT]
+func (e *Endpoint) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	var (
+		dto  DTO
+		resp response
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := e.parseGroupID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		e.logger.Warn("decode group data", zap.Error(err))
+		e.writeErr(w, r, newDecodeErr(InvalidGroupData, &dto, err))
+
+		return
+	}
+
+	model, err := e.svc.UpdateGroup(r.Context(), id, dto)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = append(resp.Data, model)
+
+	e.writeResp(w, r, resp)
+}
+
+// GetGroup http get group handler.
+// @Title Get
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description get group by id
+// @Summary get group
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param gid path string true "Group ID"
+// @Router /v1/groups/{gid} [GET]
+func (e *Endpoint) GetGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := e.parseGroupID(w, r)
+	if !ok {
+		return
+	}
+
+	model, err := e.svc.GetGroup(r.Context(), id)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	var resp response
+
+	resp.Data = append(resp.Data, model)
+
+	e.writeResp(w, r, resp)
+}
+
+// DeleteGroup http delete group handler.
+// @Title Delete
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description delete group by id
+// @Summary delete group
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 500 {object} apperr.Problem
+// @Param gid path string true "Group ID"
+// @Router /v1/groups/{gid} [DELETE]
+func (e *Endpoint) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := e.parseGroupID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.svc.DeleteGroup(r.Context(), id); err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	var resp response
+
+	resp.Data = []*Group{}
+
+	e.writeResp(w, r, resp)
+}
+
+// AddMember http add group member handler.
+// @Title AddMember
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description add user to the group
+// @Summary add group member
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param gid path string true "Group ID"
+// @Param uid path string true "User ID"
+// @Router /v1/groups/{gid}/members/{uid} [PUT]
+func (e *Endpoint) AddMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groupID, userID, ok := e.parseMemberIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.svc.AddMember(r.Context(), groupID, userID); err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	var resp response
+
+	resp.Data = []*Group{}
+
+	e.writeResp(w, r, resp)
+}
+
+// RemoveMember http remove group member handler.
+// @Title RemoveMember
+// @Tags Group
+// @Accept json
+// @Produce json,application/problem+json
+// @Description remove user from the group
+// @Summary remove group member
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 500 {object} apperr.Problem
+// @Param gid path string true "Group ID"
+// @Param uid path string true "User ID"
+// @Router /v1/groups/{gid}/members/{uid} [DELETE]
+func (e *Endpoint) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groupID, userID, ok := e.parseMemberIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := e.svc.RemoveMember(r.Context(), groupID, userID); err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	var resp response
+
+	resp.Data = []*Group{}
+
+	e.writeResp(w, r, resp)
+}
+
+func (e *Endpoint) parseGroupID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("gid"))
+	if err != nil {
+		e.logger.Warn("could not parse group id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidGroupID, err.Error()))
+
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (e *Endpoint) parseMemberIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	groupID, ok := e.parseGroupID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(r.PathValue("uid"))
+	if err != nil {
+		e.logger.Warn("could not parse user id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
+
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return groupID, userID, true
+}
+
+func (e *Endpoint) writeResp(w http.ResponseWriter, r *http.Request, uData any) {
+	data, err := json.Marshal(uData)
+	if err != nil {
+		e.writeErr(w, r, newInternalServer(InternalServerError, err.Error()))
+		e.logger.Warn("marshal data", zap.Error(err))
+		return
+	}
+
+	if _, err = w.Write(data); err != nil {
+		e.logger.Error("write error", zap.Error(err))
+	}
+}
+
+func (e *Endpoint) writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	apperr.Write(w, r, e.logger, err)
+}
@@ -0,0 +1,255 @@
+package group
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// serve routes the request through the pattern main registers the handler with.
+func serve(pattern string, h http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, h)
+	mux.ServeHTTP(w, r)
+}
+
+func TestEndpoint_CreateGroup(t *testing.T) {
+	svc := new(MockServer)
+
+	setCreate := func(dto DTO, group *Group, err error) {
+		svc.On("CreateGroup", mock.Anything, dto).Return(group, err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		body         []byte
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "success",
+			body: []byte(`{"name":"core","parentId":"8b1c7f3e-5a52-4c4c-9f51-0f3f1b7e2a10"}`),
+			setup: func() {
+				setCreate(
+					DTO{Name: "core", ParentID: &rootID},
+					&Group{
+						ID:        childID,
+						ParentID:  &rootID,
+						Name:      "core",
+						CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+					},
+					nil,
+				)
+			},
+			wantHTTPCode: http.StatusCreated,
+			want:         []byte(`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","parentId":"8b1c7f3e-5a52-4c4c-9f51-0f3f1b7e2a10","name":"core","description":"","createdAt":"2022-11-17T20:00:00Z","updatedAt":null}]}`),
+		},
+		{
+			name: "cycle",
+			body: []byte(`{"name":"core","parentId":"8b1c7f3e-5a52-4c4c-9f51-0f3f1b7e2a10"}`),
+			setup: func() {
+				setCreate(
+					DTO{Name: "core", ParentID: &rootID},
+					nil,
+					newValidationErr(GroupCycle, "group nesting would create a cycle"),
+				)
+			},
+			wantHTTPCode: http.StatusUnprocessableEntity,
+			want:         []byte(`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"group nesting would create a cycle","instance":"/v1/groups","code":"GROUP_CYCLE"}`),
+		},
+		{
+			name:         "invalid body",
+			body:         []byte(`[]`),
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"body must be valid JSON","instance":"/v1/groups","code":"INVALID_GROUP_DATA","errors":[{"rule":"json","message":"body must be valid JSON"}]}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/groups", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			e.CreateGroup(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestEndpoint_AddMember(t *testing.T) {
+	type args struct {
+		gid string
+		uid string
+	}
+
+	svc := new(MockServer)
+
+	setAddMember := func(gid, uid uuid.UUID, err error) {
+		svc.On("AddMember", mock.Anything, gid, uid).Return(err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		args         args
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "success",
+			args: args{gid: childID.String(), uid: userID.String()},
+			setup: func() {
+				setAddMember(childID, userID, nil)
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte(`{}`),
+		},
+		{
+			name: "not found",
+			args: args{gid: childID.String(), uid: userID.String()},
+			setup: func() {
+				setAddMember(childID, userID, newNotFoundErr(NotFound, "group or user not found"))
+			},
+			wantHTTPCode: http.StatusNotFound,
+			want:         []byte(`{"type":"about:blank","title":"Not Found","status":404,"detail":"group or user not found","instance":"/v1/groups/ccae37ea-d41e-4371-a3a3-89203b9e2608/members/0d8e3b16-1a57-4f0e-a0d6-59c8cbb1f1a3","code":"NOT_FOUND"}`),
+		},
+		{
+			name:         "invalid group id",
+			args:         args{gid: "invalid", uid: userID.String()},
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/groups/invalid/members/0d8e3b16-1a57-4f0e-a0d6-59c8cbb1f1a3","code":"INVALID_GROUP_ID"}`),
+		},
+		{
+			name:         "invalid user id",
+			args:         args{gid: childID.String(), uid: "invalid"},
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/groups/ccae37ea-d41e-4371-a3a3-89203b9e2608/members/invalid","code":"INVALID_USER_ID"}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/groups/"+tt.args.gid+"/members/"+tt.args.uid, nil)
+			w := httptest.NewRecorder()
+
+			serve("PUT /v1/groups/{gid}/members/{uid}", e.AddMember, w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestEndpoint_ListUserGroups(t *testing.T) {
+	svc := new(MockServer)
+
+	setListUserGroups := func(uid uuid.UUID, groups []*Group, err error) {
+		svc.On("ListUserGroups", mock.Anything, uid).Return(groups, err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		id           string
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "success",
+			id:   userID.String(),
+			setup: func() {
+				setListUserGroups(
+					userID,
+					[]*Group{
+						{
+							ID:        rootID,
+							Name:      "root",
+							CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+						},
+					},
+					nil,
+				)
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte(`{"data":[{"id":"8b1c7f3e-5a52-4c4c-9f51-0f3f1b7e2a10","parentId":null,"name":"root","description":"","createdAt":"2022-11-17T20:00:00Z","updatedAt":null}]}`),
+		},
+		{
+			name:         "invalid id",
+			id:           "invalid",
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/users/invalid/groups","code":"INVALID_USER_ID"}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/"+tt.id+"/groups", nil)
+			w := httptest.NewRecorder()
+
+			serve("GET /v1/users/{id}/groups", e.ListUserGroups, w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package group
+
+import (
+	"errors"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// service error codes.
+const (
+	InvalidGroupID      = "INVALID_GROUP_ID"
+	InvalidGroupData    = "INVALID_GROUP_DATA"
+	InvalidUserID       = "INVALID_USER_ID"
+	GroupCycle          = "GROUP_CYCLE"
+	InternalServerError = apperr.InternalServerError
+	NotFound            = apperr.NotFound
+	ValidationError     = apperr.ValidationError
+)
+
+var (
+	errNotExists       = errors.New("not exists")
+	errParentNotExists = errors.New("parent not exists")
+	errCycle           = errors.New("nesting cycle")
+)
+
+// ServiceError represent service custom error.
+type ServiceError = apperr.ServiceError
+
+func newBadRequest(code, msg string) *ServiceError {
+	return apperr.NewBadRequest(code, msg)
+}
+
+func newInternalServer(code, msg string) *ServiceError {
+	return apperr.NewInternalServer(code, msg)
+}
+
+func newNotFoundErr(code, msg string) *ServiceError {
+	return apperr.NewNotFound(code, msg)
+}
+
+func newValidationErr(code, msg string) *ServiceError {
+	return apperr.NewValidation(code, msg)
+}
+
+func newDecodeErr(code string, dst any, err error) *ServiceError {
+	return apperr.NewDecode(code, dst, err)
+}
+
+func newFieldValidationErr(code string, err error) *ServiceError {
+	return apperr.NewFieldValidation(code, err)
+}
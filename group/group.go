@@ -0,0 +1,31 @@
+package group
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// Group server domain struct.
+type Group struct {
+	ID          uuid.UUID  `json:"id"`
+	ParentID    *uuid.UUID `db:"parent_id" json:"parentId"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt   *time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// DTO represent data transfer object for creating and updating a new entity.
+type DTO struct {
+	Name        string     `validate:"required" json:"name,omitempty"`
+	Description string     `json:"description,omitempty"`
+	ParentID    *uuid.UUID `json:"parentId,omitempty"`
+}
+
+// Validate check mandatory fields.
+func (d DTO) Validate() error {
+	return apperr.ValidateStruct(d)
+}
@@ -0,0 +1,308 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/ihippik/template-service/metrics"
+	"github.com/ihippik/template-service/tenant"
+)
+
+// foreignKeyViolation is a PostgreSQL error code.
+const foreignKeyViolation = "23503"
+
+// nestingLock is the key space of the advisory locks serializing the moves of groups within a tenant.
+const nestingLock = 0x67727570
+
+// Repository is a database PostgreSQL repository.
+// Every query runs in a transaction bound to the tenant from the context.
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates new Repository instance.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// List receive all groups from the database.
+func (r *Repository) List(ctx context.Context) (_ []*Group, err error) {
+	defer metrics.ObserveQuery("group", "List", time.Now(), &err)
+
+	return r.selectGroups(
+		ctx,
+		"SELECT id, parent_id, name, description, created_at, updated_at FROM groups ORDER BY name",
+	)
+}
+
+// ListByUser receive groups the user belongs to directly or through a nested group.
+func (r *Repository) ListByUser(ctx context.Context, userID uuid.UUID) (_ []*Group, err error) {
+	defer metrics.ObserveQuery("group", "ListByUser", time.Now(), &err)
+
+	return r.selectGroups(
+		ctx,
+		`WITH RECURSIVE tree AS (
+			SELECT g.id, g.parent_id FROM groups g JOIN group_members m ON m.group_id = g.id WHERE m.user_id = $1
+			UNION
+			SELECT p.id, p.parent_id FROM groups p JOIN tree t ON p.id = t.parent_id
+		)
+		SELECT id, parent_id, name, description, created_at, updated_at FROM groups WHERE id IN (SELECT id FROM tree) ORDER BY name`,
+		userID,
+	)
+}
+
+func (r *Repository) selectGroups(ctx context.Context, query string, args ...any) ([]*Group, error) {
+	var models []*Group
+
+	err := tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		for rows.Next() {
+			var model Group
+
+			if err := rows.StructScan(&model); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("scan: %w", err)
+			}
+
+			models = append(models, &model)
+		}
+
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("close: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+// Get receive group form the database by its id.
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (_ *Group, err error) {
+	defer metrics.ObserveQuery("group", "Get", time.Now(), &err)
+
+	var model Group
+
+	err = tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx,
+			"SELECT id, parent_id, name, description, created_at, updated_at FROM groups WHERE id=$1",
+			id,
+		).StructScan(&model)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return errNotExists
+		case err != nil:
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+// Ancestors receive the chain of group ids from the group itself up to the root group.
+// An empty result means that the group does not exist.
+func (r *Repository) Ancestors(ctx context.Context, id uuid.UUID) (_ []uuid.UUID, err error) {
+	defer metrics.ObserveQuery("group", "Ancestors", time.Now(), &err)
+
+	var ids []uuid.UUID
+
+	err = tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		ids, err = ancestors(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func ancestors(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	err := tx.SelectContext(
+		ctx,
+		&ids,
+		`WITH RECURSIVE chain AS (
+			SELECT id, parent_id FROM groups WHERE id = $1
+			UNION
+			SELECT g.id, g.parent_id FROM groups g JOIN chain c ON g.id = c.parent_id
+		)
+		SELECT id FROM chain`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Update group in the database by its id. A new parent is checked in the same transaction
+// under the nesting lock of the tenant, so concurrent moves of groups can't create a cycle:
+// errParentNotExists and errCycle are returned.
+func (r *Repository) Update(ctx context.Context, group *Group) (err error) {
+	defer metrics.ObserveQuery("group", "Update", time.Now(), &err)
+
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if group.ParentID != nil {
+			if err := checkNesting(ctx, tx, group.ID, *group.ParentID); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(
+			ctx,
+			"UPDATE groups SET parent_id=$1, name=$2, description=$3, updated_at=$4 WHERE id=$5",
+			group.ParentID,
+			group.Name,
+			group.Description,
+			group.UpdatedAt,
+			group.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// checkNesting takes the nesting lock of the tenant held until the end of the transaction
+// and makes sure the parent exists and isn't a descendant of the group.
+func checkNesting(ctx context.Context, tx *sqlx.Tx, id, parentID uuid.UUID) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"SELECT pg_advisory_xact_lock($1, hashtext(current_setting('app.tenant_id')))",
+		nestingLock,
+	)
+	if err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+
+	ids, err := ancestors(ctx, tx, parentID)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return errParentNotExists
+	}
+
+	for _, ancestor := range ids {
+		if ancestor == id {
+			return errCycle
+		}
+	}
+
+	return nil
+}
+
+// Create new group in the database, the tenant column is filled from the transaction setting.
+func (r *Repository) Create(ctx context.Context, group *Group) (err error) {
+	defer metrics.ObserveQuery("group", "Create", time.Now(), &err)
+
+	return r.exec(
+		ctx,
+		"INSERT INTO groups (id, parent_id, name, description, created_at) VALUES($1, $2, $3, $4, $5)",
+		group.ID,
+		group.ParentID,
+		group.Name,
+		group.Description,
+		group.CreatedAt,
+	)
+}
+
+// Delete group from the database by its id.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	defer metrics.ObserveQuery("group", "Delete", time.Now(), &err)
+
+	return r.exec(ctx, "DELETE FROM groups WHERE id=$1", id)
+}
+
+// AddMember adds the user to the group, adding an existing member is a no-op.
+// Both entities are looked up under row-level security to report a foreign one as missing,
+// the tenant keyed foreign keys reject it anyway.
+func (r *Repository) AddMember(ctx context.Context, groupID, userID uuid.UUID, createdAt time.Time) (err error) {
+	defer metrics.ObserveQuery("group", "AddMember", time.Now(), &err)
+
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var exists bool
+
+		err := tx.GetContext(
+			ctx,
+			&exists,
+			"SELECT EXISTS(SELECT 1 FROM groups WHERE id=$1) AND EXISTS(SELECT 1 FROM users WHERE id=$2)",
+			groupID,
+			userID,
+		)
+		if err != nil {
+			return fmt.Errorf("select: %w", err)
+		}
+
+		if !exists {
+			return errNotExists
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO group_members (group_id, user_id, created_at) VALUES($1, $2, $3) ON CONFLICT DO NOTHING",
+			groupID,
+			userID,
+			createdAt,
+		)
+
+		var pqErr *pq.Error
+
+		switch {
+		case errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation:
+			return errNotExists
+		case err != nil:
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RemoveMember removes the user from the group.
+func (r *Repository) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) (err error) {
+	defer metrics.ObserveQuery("group", "RemoveMember", time.Now(), &err)
+
+	return r.exec(
+		ctx,
+		"DELETE FROM group_members WHERE group_id=$1 AND user_id=$2",
+		groupID,
+		userID,
+	)
+}
+
+func (r *Repository) exec(ctx context.Context, query string, args ...any) error {
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		return nil
+	})
+}
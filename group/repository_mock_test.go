@@ -0,0 +1,58 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) Get(ctx context.Context, id uuid.UUID) (*Group, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Group), args.Error(1)
+}
+
+func (m *MockRepo) List(ctx context.Context) ([]*Group, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*Group), args.Error(1)
+}
+
+func (m *MockRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*Group, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*Group), args.Error(1)
+}
+
+func (m *MockRepo) Ancestors(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepo) Update(ctx context.Context, group *Group) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+func (m *MockRepo) Create(ctx context.Context, group *Group) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+func (m *MockRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) AddMember(ctx context.Context, groupID, userID uuid.UUID, createdAt time.Time) error {
+	args := m.Called(ctx, groupID, userID, createdAt)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	args := m.Called(ctx, groupID, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,348 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/tenant"
+)
+
+type repo struct {
+	sql  string
+	err  error
+	rows *sqlmock.Rows
+}
+
+var columns = []string{"id", "parent_id", "name", "description", "created_at", "updated_at"}
+
+func TestRepository_Get(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	tests := []struct {
+		name    string
+		repo    repo
+		want    *Group
+		wantErr error
+	}{
+		{
+			name: "success",
+			repo: repo{
+				sql: prepareSQL(`SELECT id, parent_id, name, description, created_at, updated_at FROM groups WHERE id=$1`),
+				rows: sqlmock.NewRows(columns).AddRow(
+					childID.String(),
+					rootID.String(),
+					"core",
+					"core team",
+					time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+					nil,
+				),
+			},
+			want: &Group{
+				ID:          childID,
+				ParentID:    &rootID,
+				Name:        "core",
+				Description: "core team",
+				CreatedAt:   time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			repo: repo{
+				sql:  prepareSQL(`SELECT id, parent_id, name, description, created_at, updated_at FROM groups WHERE id=$1`),
+				err:  sql.ErrNoRows,
+				rows: sqlmock.NewRows(columns),
+			},
+			want:    nil,
+			wantErr: errors.New("not exists"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+			mock.ExpectQuery(tt.repo.sql).
+				WithArgs(childID).
+				WillReturnRows(tt.repo.rows).
+				WillReturnError(tt.repo.err)
+			expectTxEnd(mock, tt.wantErr)
+
+			got, err := r.Get(tenantCtx, childID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRepository_Ancestors(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	tests := []struct {
+		name    string
+		repo    repo
+		want    []uuid.UUID
+		wantErr error
+	}{
+		{
+			name: "success",
+			repo: repo{
+				sql:  `WITH RECURSIVE chain AS`,
+				rows: sqlmock.NewRows([]string{"id"}).AddRow(childID.String()).AddRow(rootID.String()),
+			},
+			want:    []uuid.UUID{childID, rootID},
+			wantErr: nil,
+		},
+		{
+			name: "some err",
+			repo: repo{
+				sql:  `WITH RECURSIVE chain AS`,
+				err:  errors.New("some err"),
+				rows: sqlmock.NewRows([]string{"id"}),
+			},
+			want:    nil,
+			wantErr: errors.New("select: some err"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+			mock.ExpectQuery(tt.repo.sql).
+				WithArgs(childID).
+				WillReturnRows(tt.repo.rows).
+				WillReturnError(tt.repo.err)
+			expectTxEnd(mock, tt.wantErr)
+
+			got, err := r.Ancestors(tenantCtx, childID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRepository_Update(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	updatedAt := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		parentID  *uuid.UUID
+		ancestors *sqlmock.Rows
+		wantErr   error
+	}{
+		{
+			name:      "success",
+			parentID:  &rootID,
+			ancestors: sqlmock.NewRows([]string{"id"}).AddRow(rootID.String()),
+			wantErr:   nil,
+		},
+		{
+			name:    "root",
+			wantErr: nil,
+		},
+		{
+			name:      "parent not found",
+			parentID:  &rootID,
+			ancestors: sqlmock.NewRows([]string{"id"}),
+			wantErr:   errParentNotExists,
+		},
+		{
+			name:      "cycle",
+			parentID:  &rootID,
+			ancestors: sqlmock.NewRows([]string{"id"}).AddRow(rootID.String()).AddRow(childID.String()),
+			wantErr:   errCycle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+
+			if tt.parentID != nil {
+				mock.ExpectExec(prepareSQL(`SELECT pg_advisory_xact_lock($1, hashtext(current_setting('app.tenant_id')))`)).
+					WithArgs(nestingLock).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`WITH RECURSIVE chain AS`).
+					WithArgs(*tt.parentID).
+					WillReturnRows(tt.ancestors)
+			}
+
+			if tt.wantErr == nil {
+				mock.ExpectExec(prepareSQL(`UPDATE groups SET parent_id=$1, name=$2, description=$3, updated_at=$4 WHERE id=$5`)).
+					WithArgs(tt.parentID, "core", "core team", &updatedAt, childID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			expectTxEnd(mock, tt.wantErr)
+
+			err := r.Update(tenantCtx, &Group{
+				ID:          childID,
+				ParentID:    tt.parentID,
+				Name:        "core",
+				Description: "core team",
+				UpdatedAt:   &updatedAt,
+			})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_ListByUser(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery(`WITH RECURSIVE tree AS`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			rootID.String(),
+			nil,
+			"root",
+			"",
+			time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+			nil,
+		))
+	mock.ExpectCommit()
+
+	got, err := r.ListByUser(tenantCtx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Group{
+		{
+			ID:        rootID,
+			Name:      "root",
+			CreatedAt: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}, got)
+}
+
+func TestRepository_AddMember(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	createdAt := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		exists  bool
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "success",
+			exists:  true,
+			wantErr: nil,
+		},
+		{
+			name:    "not exists in tenant",
+			exists:  false,
+			wantErr: errors.New("not exists"),
+		},
+		{
+			name:    "foreign key violation",
+			exists:  true,
+			err:     &pq.Error{Code: foreignKeyViolation},
+			wantErr: errors.New("not exists"),
+		},
+		{
+			name:    "some err",
+			exists:  true,
+			err:     errors.New("some err"),
+			wantErr: errors.New("exec: some err"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+			mock.ExpectQuery(prepareSQL(`SELECT EXISTS(SELECT 1 FROM groups WHERE id=$1) AND EXISTS(SELECT 1 FROM users WHERE id=$2)`)).
+				WithArgs(childID, userID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tt.exists))
+
+			if tt.exists {
+				mock.ExpectExec(prepareSQL(`INSERT INTO group_members (group_id, user_id, created_at) VALUES($1, $2, $3) ON CONFLICT DO NOTHING`)).
+					WithArgs(childID, userID, createdAt).
+					WillReturnResult(sqlmock.NewResult(0, 1)).
+					WillReturnError(tt.err)
+			}
+
+			expectTxEnd(mock, tt.wantErr)
+
+			err := r.AddMember(tenantCtx, childID, userID, createdAt)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func prepareSQL(sql string) string {
+	replacer := strings.NewReplacer("$", "\\$", "(", "\\(", ")", "\\)")
+	return replacer.Replace(sql)
+}
+
+var tenantCtx = tenant.WithID(context.Background(), uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d"))
+
+func expectTenantTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(prepareSQL(`SELECT set_config('app.tenant_id', $1, true)`)).
+		WithArgs("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectTxEnd(mock sqlmock.Sqlmock, err error) {
+	if err != nil {
+		mock.ExpectRollback()
+		return
+	}
+
+	mock.ExpectCommit()
+}
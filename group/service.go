@@ -0,0 +1,246 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/logging"
+	"github.com/ihippik/template-service/tracing"
+)
+
+type repository interface {
+	Get(ctx context.Context, id uuid.UUID) (*Group, error)
+	List(ctx context.Context) ([]*Group, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*Group, error)
+	Ancestors(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	Update(ctx context.Context, group *Group) error
+	Create(ctx context.Context, group *Group) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	AddMember(ctx context.Context, groupID, userID uuid.UUID, createdAt time.Time) error
+	RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error
+}
+
+// Service represent the group management logic.
+type Service struct {
+	cfg    *config.Config
+	logger *zap.Logger
+	repo   repository
+}
+
+var timeNow = time.Now
+
+// NewService creates new Service entity.
+func NewService(cfg *config.Config, logger *zap.Logger, repo repository) *Service {
+	return &Service{cfg: cfg, logger: logger, repo: repo}
+}
+
+// GetGroup get group entity by its identification.
+func (svc *Service) GetGroup(ctx context.Context, id uuid.UUID) (_ *Group, err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.GetGroup")
+	defer tracing.End(span, &err)
+
+	model, err := svc.repo.Get(ctx, id)
+	if err == nil {
+		return model, nil
+	}
+
+	if errors.Is(err, errNotExists) {
+		svc.log(ctx).Warn("group not found", zap.String("id", id.String()))
+		return nil, newNotFoundErr(NotFound, "group not found")
+	}
+
+	svc.log(ctx).Error("could not get group", zap.Error(err))
+
+	return nil, fmt.Errorf("could not get group: %w", err)
+}
+
+// ListGroups fetch all groups.
+func (svc *Service) ListGroups(ctx context.Context) (_ []*Group, err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.ListGroups")
+	defer tracing.End(span, &err)
+
+	models, err := svc.repo.List(ctx)
+	if err != nil {
+		svc.log(ctx).Error("could not fetch groups", zap.Error(err))
+		return nil, fmt.Errorf("list: %w", err)
+	}
+
+	return models, nil
+}
+
+// ListUserGroups fetch groups the user belongs to, including parents of her groups.
+func (svc *Service) ListUserGroups(ctx context.Context, userID uuid.UUID) (_ []*Group, err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.ListUserGroups")
+	defer tracing.End(span, &err)
+
+	models, err := svc.repo.ListByUser(ctx, userID)
+	if err != nil {
+		svc.log(ctx).Error("could not fetch user groups", zap.Error(err))
+		return nil, fmt.Errorf("list by user: %w", err)
+	}
+
+	return models, nil
+}
+
+// CreateGroup create new entity group.
+func (svc *Service) CreateGroup(ctx context.Context, dto DTO) (_ *Group, err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.CreateGroup")
+	defer tracing.End(span, &err)
+
+	if err := dto.Validate(); err != nil {
+		svc.log(ctx).Warn("dto validation error", zap.Error(err))
+		return nil, newFieldValidationErr(ValidationError, err)
+	}
+
+	model := Group{
+		ID:          uuid.New(),
+		ParentID:    dto.ParentID,
+		Name:        dto.Name,
+		Description: dto.Description,
+		CreatedAt:   timeNow().UTC(),
+	}
+
+	if err := svc.checkParent(ctx, model.ParentID); err != nil {
+		return nil, err
+	}
+
+	if err := svc.repo.Create(ctx, &model); err != nil {
+		svc.log(ctx).Error("could not create group", zap.Error(err))
+		return nil, fmt.Errorf("could not create group: %w", err)
+	}
+
+	return &model, nil
+}
+
+// UpdateGroup update group entity by its identification.
+func (svc *Service) UpdateGroup(ctx context.Context, id uuid.UUID, dto DTO) (_ *Group, err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.UpdateGroup")
+	defer tracing.End(span, &err)
+
+	if err := dto.Validate(); err != nil {
+		svc.log(ctx).Warn("dto validation error", zap.Error(err))
+		return nil, newFieldValidationErr(ValidationError, err)
+	}
+
+	model, err := svc.GetGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if dto.ParentID != nil && *dto.ParentID == id {
+		svc.log(ctx).Warn("group can not be its own parent", zap.String("id", id.String()))
+		return nil, newValidationErr(GroupCycle, "group can not be its own parent")
+	}
+
+	now := timeNow().UTC()
+
+	model.UpdatedAt = &now
+	model.ParentID = dto.ParentID
+	model.Name = dto.Name
+	model.Description = dto.Description
+
+	// the parent is checked by the repository in the transaction of the update.
+	err = svc.repo.Update(ctx, model)
+
+	switch {
+	case errors.Is(err, errParentNotExists):
+		svc.log(ctx).Warn("parent group not found", zap.String("id", dto.ParentID.String()))
+		return nil, newNotFoundErr(NotFound, "parent group not found")
+	case errors.Is(err, errCycle):
+		svc.log(ctx).Warn(
+			"group nesting cycle",
+			zap.String("id", id.String()),
+			zap.String("parent_id", dto.ParentID.String()),
+		)
+
+		return nil, newValidationErr(GroupCycle, "group nesting would create a cycle")
+	case err != nil:
+		svc.log(ctx).Error("update group error", zap.Error(err))
+		return nil, fmt.Errorf("update group: %w", err)
+	}
+
+	return model, nil
+}
+
+// DeleteGroup delete a group by its identification, subgroups become root groups.
+func (svc *Service) DeleteGroup(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.DeleteGroup")
+	defer tracing.End(span, &err)
+
+	if err := svc.repo.Delete(ctx, id); err != nil {
+		svc.log(ctx).Error("could not delete group", zap.Error(err))
+		return fmt.Errorf("delete group: %w", err)
+	}
+
+	return nil
+}
+
+// AddMember adds the user to the group.
+func (svc *Service) AddMember(ctx context.Context, groupID, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.AddMember")
+	defer tracing.End(span, &err)
+
+	err = svc.repo.AddMember(ctx, groupID, userID, timeNow().UTC())
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, errNotExists) {
+		svc.log(ctx).Warn(
+			"group or user not found",
+			zap.String("group_id", groupID.String()),
+			zap.String("user_id", userID.String()),
+		)
+
+		return newNotFoundErr(NotFound, "group or user not found")
+	}
+
+	svc.log(ctx).Error("could not add group member", zap.Error(err))
+
+	return fmt.Errorf("add member: %w", err)
+}
+
+// RemoveMember removes the user from the group.
+func (svc *Service) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "group.Service.RemoveMember")
+	defer tracing.End(span, &err)
+
+	if err := svc.repo.RemoveMember(ctx, groupID, userID); err != nil {
+		svc.log(ctx).Error("could not remove group member", zap.Error(err))
+		return fmt.Errorf("remove member: %w", err)
+	}
+
+	return nil
+}
+
+// checkParent makes sure that the parent group of the new group exists,
+// a new group has no subgroups to produce a cycle.
+func (svc *Service) checkParent(ctx context.Context, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+
+	ancestors, err := svc.repo.Ancestors(ctx, *parentID)
+	if err != nil {
+		svc.log(ctx).Error("could not get group ancestors", zap.Error(err))
+		return fmt.Errorf("ancestors: %w", err)
+	}
+
+	if len(ancestors) == 0 {
+		svc.log(ctx).Warn("parent group not found", zap.String("id", parentID.String()))
+		return newNotFoundErr(NotFound, "parent group not found")
+	}
+
+	return nil
+}
+
+// log returns the request-scoped logger of the context.
+func (svc *Service) log(ctx context.Context) *zap.Logger {
+	return logging.FromContext(ctx, svc.logger)
+}
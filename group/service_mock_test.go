@@ -0,0 +1,52 @@
+package group
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockServer struct {
+	mock.Mock
+}
+
+func (m *MockServer) GetGroup(ctx context.Context, id uuid.UUID) (*Group, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Group), args.Error(1)
+}
+
+func (m *MockServer) ListGroups(ctx context.Context) ([]*Group, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*Group), args.Error(1)
+}
+
+func (m *MockServer) ListUserGroups(ctx context.Context, userID uuid.UUID) ([]*Group, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*Group), args.Error(1)
+}
+
+func (m *MockServer) UpdateGroup(ctx context.Context, id uuid.UUID, dto DTO) (*Group, error) {
+	args := m.Called(ctx, id, dto)
+	return args.Get(0).(*Group), args.Error(1)
+}
+
+func (m *MockServer) CreateGroup(ctx context.Context, dto DTO) (*Group, error) {
+	args := m.Called(ctx, dto)
+	return args.Get(0).(*Group), args.Error(1)
+}
+
+func (m *MockServer) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockServer) AddMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	args := m.Called(ctx, groupID, userID)
+	return args.Error(0)
+}
+
+func (m *MockServer) RemoveMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	args := m.Called(ctx, groupID, userID)
+	return args.Error(0)
+}
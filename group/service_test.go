@@ -0,0 +1,487 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+var (
+	rootID  = uuid.MustParse("8b1c7f3e-5a52-4c4c-9f51-0f3f1b7e2a10")
+	childID = uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+	userID  = uuid.MustParse("0d8e3b16-1a57-4f0e-a0d6-59c8cbb1f1a3")
+)
+
+func TestService_GetGroup(t *testing.T) {
+	repo := new(MockRepo)
+
+	setGet := func(id uuid.UUID, group *Group, err error) {
+		repo.On("Get", mock.Anything, id).Return(group, err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		want    *Group
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				setGet(childID, &Group{ID: childID, Name: "core"}, nil)
+			},
+			want:    &Group{ID: childID, Name: "core"},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			setup: func() {
+				setGet(childID, nil, errNotExists)
+			},
+			want:    nil,
+			wantErr: newNotFoundErr(NotFound, "group not found"),
+		},
+		{
+			name: "some error",
+			setup: func() {
+				setGet(childID, nil, errors.New("some error"))
+			},
+			want:    nil,
+			wantErr: errors.New("could not get group: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.GetGroup(context.Background(), childID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_CreateGroup(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	newID := uuid.MustParse("31313131-3131-4131-b131-313131313131")
+
+	repo := new(MockRepo)
+
+	setAncestors := func(id uuid.UUID, ids []uuid.UUID, err error) {
+		repo.On("Ancestors", mock.Anything, id).Return(ids, err).Once()
+	}
+
+	setCreate := func(group *Group, err error) {
+		repo.On("Create", mock.Anything, group).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		dto     DTO
+		setup   func()
+		want    *Group
+		wantErr error
+	}{
+		{
+			name: "success",
+			dto:  DTO{Name: "core", ParentID: &rootID},
+			setup: func() {
+				setAncestors(rootID, []uuid.UUID{rootID}, nil)
+				setCreate(
+					&Group{
+						ID:        newID,
+						ParentID:  &rootID,
+						Name:      "core",
+						CreatedAt: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+					},
+					nil,
+				)
+			},
+			want: &Group{
+				ID:        newID,
+				ParentID:  &rootID,
+				Name:      "core",
+				CreatedAt: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "validation error",
+			dto:     DTO{Description: "no name"},
+			setup:   func() {},
+			want:    nil,
+			wantErr: errors.New("name is required"),
+		},
+		{
+			name: "parent not found",
+			dto:  DTO{Name: "core", ParentID: &rootID},
+			setup: func() {
+				setAncestors(rootID, nil, nil)
+			},
+			want:    nil,
+			wantErr: newNotFoundErr(NotFound, "parent group not found"),
+		},
+		{
+			name: "some error",
+			dto:  DTO{Name: "core"},
+			setup: func() {
+				setCreate(
+					&Group{
+						ID:        newID,
+						Name:      "core",
+						CreatedAt: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+					},
+					errors.New("some error"),
+				)
+			},
+			want:    nil,
+			wantErr: errors.New("could not create group: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			uuid.SetRand(bytes.NewReader([]byte("1111111111111111")))
+
+			tt.setup()
+
+			got, err := svc.CreateGroup(context.Background(), tt.dto)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_UpdateGroup(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	repo := new(MockRepo)
+
+	setGet := func(id uuid.UUID, group *Group, err error) {
+		repo.On("Get", mock.Anything, id).Return(group, err).Once()
+	}
+
+	setUpdate := func(group *Group, err error) {
+		repo.On("Update", mock.Anything, group).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		dto     DTO
+		setup   func()
+		want    *Group
+		wantErr error
+	}{
+		{
+			name: "success",
+			id:   childID,
+			dto:  DTO{Name: "platform", ParentID: &rootID},
+			setup: func() {
+				setGet(childID, &Group{ID: childID, Name: "core"}, nil)
+				setUpdate(
+					&Group{
+						ID:        childID,
+						ParentID:  &rootID,
+						Name:      "platform",
+						UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					nil,
+				)
+			},
+			want: &Group{
+				ID:        childID,
+				ParentID:  &rootID,
+				Name:      "platform",
+				UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "group not found",
+			id:   childID,
+			dto:  DTO{Name: "platform"},
+			setup: func() {
+				setGet(childID, nil, errNotExists)
+			},
+			want:    nil,
+			wantErr: newNotFoundErr(NotFound, "group not found"),
+		},
+		{
+			name: "own parent",
+			id:   childID,
+			dto:  DTO{Name: "platform", ParentID: &childID},
+			setup: func() {
+				setGet(childID, &Group{ID: childID, Name: "core"}, nil)
+			},
+			want:    nil,
+			wantErr: newValidationErr(GroupCycle, "group can not be its own parent"),
+		},
+		{
+			name: "cycle",
+			id:   rootID,
+			dto:  DTO{Name: "root", ParentID: &childID},
+			setup: func() {
+				setGet(rootID, &Group{ID: rootID, Name: "root"}, nil)
+				setUpdate(
+					&Group{
+						ID:        rootID,
+						ParentID:  &childID,
+						Name:      "root",
+						UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					errCycle,
+				)
+			},
+			want:    nil,
+			wantErr: newValidationErr(GroupCycle, "group nesting would create a cycle"),
+		},
+		{
+			name: "parent not found",
+			id:   childID,
+			dto:  DTO{Name: "platform", ParentID: &rootID},
+			setup: func() {
+				setGet(childID, &Group{ID: childID, Name: "core"}, nil)
+				setUpdate(
+					&Group{
+						ID:        childID,
+						ParentID:  &rootID,
+						Name:      "platform",
+						UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					errParentNotExists,
+				)
+			},
+			want:    nil,
+			wantErr: newNotFoundErr(NotFound, "parent group not found"),
+		},
+		{
+			name: "update: some error",
+			id:   childID,
+			dto:  DTO{Name: "platform"},
+			setup: func() {
+				setGet(childID, &Group{ID: childID, Name: "core"}, nil)
+				setUpdate(
+					&Group{
+						ID:        childID,
+						Name:      "platform",
+						UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					errors.New("some error"),
+				)
+			},
+			want:    nil,
+			wantErr: errors.New("update group: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.UpdateGroup(context.Background(), tt.id, tt.dto)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_AddMember(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	repo := new(MockRepo)
+
+	setAddMember := func(err error) {
+		repo.On("AddMember", mock.Anything, childID, userID, time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)).
+			Return(err).
+			Once()
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				setAddMember(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			setup: func() {
+				setAddMember(errNotExists)
+			},
+			wantErr: newNotFoundErr(NotFound, "group or user not found"),
+		},
+		{
+			name: "some error",
+			setup: func() {
+				setAddMember(errors.New("some error"))
+			},
+			wantErr: errors.New("add member: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			err := svc.AddMember(context.Background(), childID, userID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_RemoveMember(t *testing.T) {
+	repo := new(MockRepo)
+
+	setRemoveMember := func(err error) {
+		repo.On("RemoveMember", mock.Anything, childID, userID).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				setRemoveMember(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "some error",
+			setup: func() {
+				setRemoveMember(errors.New("some error"))
+			},
+			wantErr: errors.New("remove member: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			err := svc.RemoveMember(context.Background(), childID, userID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_ListUserGroups(t *testing.T) {
+	repo := new(MockRepo)
+
+	setListByUser := func(groups []*Group, err error) {
+		repo.On("ListByUser", mock.Anything, userID).Return(groups, err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		want    []*Group
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				setListByUser([]*Group{{ID: rootID, Name: "root"}, {ID: childID, ParentID: &rootID, Name: "core"}}, nil)
+			},
+			want:    []*Group{{ID: rootID, Name: "root"}, {ID: childID, ParentID: &rootID, Name: "core"}},
+			wantErr: nil,
+		},
+		{
+			name: "some error",
+			setup: func() {
+				setListByUser(nil, errors.New("some error"))
+			},
+			want:    nil,
+			wantErr: errors.New("list by user: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.ListUserGroups(context.Background(), userID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func toPointer[T any](d T) *T {
+	return &d
+}
@@ -0,0 +1,182 @@
+// Package health serves liveness and readiness probes, readiness runs the registered dependency checks.
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+)
+
+// check statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ShutdownCheck is the readiness check failing once the server started shutting down.
+const ShutdownCheck = "shutdown"
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// CheckFunc reports whether the dependency is usable, the context carries the check timeout.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of the single check.
+type CheckResult struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+// Report is the probe response body.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker runs readiness checks of the service dependencies.
+type Checker struct {
+	logger       *zap.Logger
+	timeout      time.Duration
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+// NewChecker creates new Checker, every check must finish within the timeout.
+func NewChecker(logger *zap.Logger, timeout time.Duration) *Checker {
+	return &Checker{logger: logger, timeout: timeout}
+}
+
+// Register adds the readiness check, it must be called before the server starts.
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Shutdown makes the service not ready, so the load balancer stops sending traffic before the server stops.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Live reports the process is alive, it never depends on other services.
+func (c *Checker) Live(w http.ResponseWriter, _ *http.Request) {
+	c.write(w, Report{Status: StatusOK})
+}
+
+// Ready runs all checks concurrently and reports 503 when any of them failed.
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	c.write(w, c.Run(r.Context()))
+}
+
+// Run executes the checks and returns the report.
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.checks)+1)}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, ch := range c.checks {
+		wg.Add(1)
+
+		go func(ch check) {
+			defer wg.Done()
+
+			res := run(ctx, ch.fn)
+
+			mu.Lock()
+			report.Checks[ch.name] = res
+			mu.Unlock()
+		}(ch)
+	}
+
+	wg.Wait()
+
+	report.Checks[ShutdownCheck] = CheckResult{Status: StatusOK, Latency: "0s"}
+
+	if c.shuttingDown.Load() {
+		report.Checks[ShutdownCheck] = CheckResult{Status: StatusFail, Error: errShuttingDown.Error(), Latency: "0s"}
+	}
+
+	names := make([]string, 0, len(report.Checks))
+
+	for name := range report.Checks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if res := report.Checks[name]; res.Status == StatusFail {
+			report.Status = StatusFail
+
+			c.logger.Warn("readiness check failed", zap.String("check", name), zap.String("error", res.Error))
+		}
+	}
+
+	return report
+}
+
+func run(ctx context.Context, fn CheckFunc) CheckResult {
+	start := time.Now()
+
+	errCh := make(chan error, 1)
+
+	go func() { errCh <- fn(ctx) }()
+
+	var err error
+
+	// a check ignoring the context can't hold the probe past the timeout.
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{Status: StatusOK, Latency: time.Since(start).Round(time.Microsecond).String()}
+
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+func (c *Checker) write(w http.ResponseWriter, report Report) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		c.logger.Error("marshal health report", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(data); err != nil {
+		c.logger.Error("write health report", zap.Error(err))
+	}
+}
@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestChecker_Live(t *testing.T) {
+	checker := NewChecker(zap.NewNop(), time.Second)
+	checker.Register("db", func(context.Context) error { return errors.New("down") })
+	checker.Shutdown()
+
+	rec := httptest.NewRecorder()
+
+	checker.Live(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+func TestChecker_Ready(t *testing.T) {
+	type check struct {
+		name string
+		fn   CheckFunc
+	}
+
+	tests := []struct {
+		name         string
+		checks       []check
+		shutdown     bool
+		wantCode     int
+		wantStatus   string
+		wantStatuses map[string]string
+		wantErrors   map[string]string
+	}{
+		{
+			name: "success",
+			checks: []check{
+				{name: "db", fn: func(context.Context) error { return nil }},
+				{name: "migrations", fn: func(context.Context) error { return nil }},
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusOK,
+			wantStatuses: map[string]string{
+				"db":          StatusOK,
+				"migrations":  StatusOK,
+				ShutdownCheck: StatusOK,
+			},
+			wantErrors: map[string]string{},
+		},
+		{
+			name: "check failed",
+			checks: []check{
+				{name: "db", fn: func(context.Context) error { return nil }},
+				{name: "migrations", fn: func(context.Context) error { return errors.New("schema version 1, expected 2") }},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusFail,
+			wantStatuses: map[string]string{
+				"db":          StatusOK,
+				"migrations":  StatusFail,
+				ShutdownCheck: StatusOK,
+			},
+			wantErrors: map[string]string{"migrations": "schema version 1, expected 2"},
+		},
+		{
+			name: "timeout",
+			checks: []check{
+				{name: "db", fn: func(context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				}},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusFail,
+			wantStatuses: map[string]string{
+				"db":          StatusFail,
+				ShutdownCheck: StatusOK,
+			},
+			wantErrors: map[string]string{"db": context.DeadlineExceeded.Error()},
+		},
+		{
+			name:       "shutting down",
+			shutdown:   true,
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusFail,
+			wantStatuses: map[string]string{
+				ShutdownCheck: StatusFail,
+			},
+			wantErrors: map[string]string{ShutdownCheck: "server is shutting down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(zap.NewNop(), 50*time.Millisecond)
+
+			for _, c := range tt.checks {
+				checker.Register(c.name, c.fn)
+			}
+
+			if tt.shutdown {
+				checker.Shutdown()
+			}
+
+			rec := httptest.NewRecorder()
+
+			checker.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var report Report
+
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tt.wantStatus, report.Status)
+
+			statuses := make(map[string]string, len(report.Checks))
+			errs := make(map[string]string)
+
+			for name, res := range report.Checks {
+				statuses[name] = res.Status
+
+				if res.Error != "" {
+					errs[name] = res.Error
+				}
+			}
+
+			assert.Equal(t, tt.wantStatuses, statuses)
+			assert.Equal(t, tt.wantErrors, errs)
+		})
+	}
+}
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"fmt"
+
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/metrics"
 )
 
-func iniLogger(cfg config.LogCfg, version string) (*zap.Logger, error) {
+// iniLogger builds the logger, its level can be changed at runtime through the returned AtomicLevel.
+func iniLogger(cfg config.LogCfg, version string) (*zap.Logger, zap.AtomicLevel, error) {
 	lCfg := zap.NewProductionConfig()
 	lCfg.DisableCaller = !cfg.Caller
 	lCfg.DisableStacktrace = !cfg.StackTrace
@@ -26,26 +32,46 @@ func iniLogger(cfg config.LogCfg, version string) (*zap.Logger, error) {
 		lCfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 	case "warn":
 		lCfg.Level = zap.NewAtomicLevelAt(zapcore.WarnLevel)
+	case "error":
+		lCfg.Level = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
 	default:
 		lCfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
 	}
 
 	logger, err := lCfg.Build()
 	if err != nil {
-		return nil, err
+		return nil, lCfg.Level, err
 	}
 
-	return logger.With(zap.Field{Key: "version", Type: zapcore.StringType, String: version}), nil
+	return logger.With(zap.Field{Key: "version", Type: zapcore.StringType, String: version}), lCfg.Level, nil
 }
 
 func initConn(cfg config.DBCfg) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("postgres", cfg.Conn)
+	// every SQL statement gets a span of the request trace.
+	sqlDB, err := otelsql.Open(
+		"postgres",
+		cfg.Conn,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
 	if err != nil {
 		return nil, err
 	}
 
+	db := sqlx.NewDb(sqlDB, "postgres")
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 
+	if err := metrics.RegisterDB(db.DB, "template"); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("register db metrics: %w", err)
+	}
+
 	return db, nil
 }
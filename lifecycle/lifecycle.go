@@ -0,0 +1,180 @@
+// Package lifecycle starts the components of the service in order and stops them in reverse,
+// every stop is bounded by the timeout of the component.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrForced is wrapped by the Stop error when a component didn't stop within its timeout.
+var ErrForced = errors.New("shutdown was forced")
+
+// Component is a part of the service with its own lifecycle, Start must return once the component runs.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// BlockedError lists the components which didn't stop within their timeouts.
+type BlockedError struct {
+	Components []string
+}
+
+// Error implements error interface.
+func (e *BlockedError) Error() string {
+	return "blocked by " + strings.Join(e.Components, ", ")
+}
+
+// Unwrap returns ErrForced.
+func (e *BlockedError) Unwrap() error {
+	return ErrForced
+}
+
+// Manager runs the components, stop timeouts are looked up by the component name.
+type Manager struct {
+	logger     *zap.Logger
+	timeout    time.Duration
+	timeouts   map[string]time.Duration
+	components []Component
+	next       int
+	started    []Component
+}
+
+// New creates new Manager, the timeout applies to components without their own one.
+func New(logger *zap.Logger, timeout time.Duration, timeouts map[string]time.Duration) *Manager {
+	return &Manager{logger: logger, timeout: timeout, timeouts: timeouts}
+}
+
+// Add appends the component, components start in the order they were added.
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+// Start starts the components added since the previous Start in order, on failure all started ones are stopped.
+func (m *Manager) Start(ctx context.Context) error {
+	for ; m.next < len(m.components); m.next++ {
+		c := m.components[m.next]
+
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", c.Name, err)
+
+				if stopErr := m.Stop(context.WithoutCancel(ctx)); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+
+				m.next = len(m.components)
+
+				return err
+			}
+		}
+
+		m.started = append(m.started, c)
+
+		m.logger.Debug("component was started", zap.String("component", c.Name))
+	}
+
+	return nil
+}
+
+// Stop stops the started components in reverse order. A component not stopped within its timeout
+// is left behind and reported as blocked, the error then wraps ErrForced.
+func (m *Manager) Stop(ctx context.Context) error {
+	var (
+		errs    []error
+		blocked []string
+	)
+
+	for i := len(m.started) - 1; i >= 0; i-- {
+		c := m.started[i]
+
+		if c.Stop == nil {
+			continue
+		}
+
+		timeout := m.timeout
+		if t, ok := m.timeouts[c.Name]; ok {
+			timeout = t
+		}
+
+		start := time.Now()
+
+		err := stop(ctx, c, timeout)
+
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			m.logger.Error("component blocked shutdown", zap.String("component", c.Name), zap.Duration("timeout", timeout))
+			blocked = append(blocked, c.Name)
+		case err != nil:
+			m.logger.Error("component stopped with error", zap.String("component", c.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name, err))
+		default:
+			m.logger.Info("component was stopped", zap.String("component", c.Name), zap.Duration("took", time.Since(start)))
+		}
+	}
+
+	m.started = nil
+
+	if len(blocked) > 0 {
+		errs = append(errs, &BlockedError{Components: blocked})
+	}
+
+	return errors.Join(errs...)
+}
+
+// stop runs the stop of the component, it doesn't wait past the timeout for the stop ignoring the context.
+func stop(ctx context.Context, c Component, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() { done <- c.Stop(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Worker is the component running fn in the background until it's stopped,
+// the stop waits for fn to return.
+func Worker(name string, fn func(ctx context.Context)) Component {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			ctx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+			go func() {
+				defer close(done)
+				fn(ctx)
+			}()
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
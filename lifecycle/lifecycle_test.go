@@ -0,0 +1,157 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestManager(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+
+	call := func(c string) {
+		mu.Lock()
+		calls = append(calls, c)
+		mu.Unlock()
+	}
+
+	component := func(name string, startErr error, block bool) Component {
+		return Component{
+			Name: name,
+			Start: func(context.Context) error {
+				call("start " + name)
+				return startErr
+			},
+			Stop: func(ctx context.Context) error {
+				call("stop " + name)
+
+				if block {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		components []Component
+		wantCalls  []string
+		wantErr    string
+		wantForced bool
+	}{
+		{
+			name:       "success",
+			components: []Component{component("db", nil, false), component("http", nil, false)},
+			wantCalls:  []string{"start db", "start http", "stop http", "stop db"},
+		},
+		{
+			name: "blocked",
+			components: []Component{
+				component("db", nil, false),
+				component("http", nil, true),
+				component("drain", nil, false),
+			},
+			wantCalls:  []string{"start db", "start http", "start drain", "stop drain", "stop http", "stop db"},
+			wantErr:    "blocked by http",
+			wantForced: true,
+		},
+		{
+			name: "stop err",
+			components: []Component{
+				component("db", nil, false),
+				{Name: "http", Stop: func(context.Context) error { return errors.New("some err") }},
+			},
+			wantCalls: []string{"start db", "stop db"},
+			wantErr:   "stop http: some err",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			calls = nil
+			mu.Unlock()
+
+			m := New(zap.NewNop(), time.Second, map[string]time.Duration{"http": 10 * time.Millisecond})
+
+			for _, c := range tt.components {
+				m.Add(c)
+			}
+
+			require.NoError(t, m.Start(context.Background()))
+
+			err := m.Stop(context.Background())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantForced, errors.Is(err, ErrForced))
+
+			mu.Lock()
+			assert.Equal(t, tt.wantCalls, calls)
+			mu.Unlock()
+
+			// stopped components are not stopped again.
+			assert.NoError(t, m.Stop(context.Background()))
+		})
+	}
+}
+
+func TestManager_Start(t *testing.T) {
+	var calls []string
+
+	m := New(zap.NewNop(), time.Second, nil)
+
+	m.Add(Component{Name: "db", Stop: func(context.Context) error {
+		calls = append(calls, "stop db")
+		return nil
+	}})
+
+	require.NoError(t, m.Start(context.Background()))
+
+	m.Add(Component{Name: "http", Start: func(context.Context) error { return errors.New("address in use") }})
+
+	err := m.Start(context.Background())
+	assert.EqualError(t, err, "start http: address in use")
+	assert.Equal(t, []string{"stop db"}, calls)
+}
+
+func TestWorker(t *testing.T) {
+	stopped := make(chan struct{})
+
+	w := Worker("cleanup", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	require.NoError(t, w.Start(ctx))
+
+	// the worker outlives the start context until it's stopped.
+	cancel()
+
+	select {
+	case <-stopped:
+		t.Fatal("worker stopped with the start context")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	require.NoError(t, w.Stop(context.Background()))
+
+	<-stopped
+}
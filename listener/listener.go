@@ -0,0 +1,154 @@
+// Package listener opens the listeners of the server: TCP, a Unix domain socket
+// and sockets passed by systemd socket activation.
+package listener
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ihippik/template-service/config"
+)
+
+// systemd socket activation variables, the passed descriptors start at 3.
+const (
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+	firstFD          = 3
+)
+
+// Listen opens the listeners of the config. Sockets passed by systemd take precedence over the TCP address,
+// which isn't listened on then as the socket unit owns the port, the Unix socket of the config is added to them.
+// After an upgrade all listeners are taken from the previous process.
+func Listen(addr string, socket config.SocketCfg) ([]net.Listener, error) {
+	listeners, err := inherited(Server)
+	if err != nil {
+		return nil, fmt.Errorf("inherit: %w", err)
+	}
+
+	if len(listeners) > 0 {
+		return listeners, nil
+	}
+
+	activated, err := Systemd()
+	if err != nil {
+		return nil, fmt.Errorf("systemd: %w", err)
+	}
+
+	return listen(addr, socket, activated)
+}
+
+// listen adds the TCP listener when systemd passed no sockets and the Unix socket when it's configured.
+func listen(addr string, socket config.SocketCfg, listeners []net.Listener) ([]net.Listener, error) {
+	if len(listeners) == 0 {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	if socket.Path != "" {
+		mode, err := strconv.ParseUint(socket.Mode, 8, 32)
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("socket mode: %w", err)
+		}
+
+		l, err := Unix(socket.Path, fs.FileMode(mode))
+		if err != nil {
+			closeAll(listeners)
+			return nil, err
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
+// Unix listens on the Unix domain socket, a stale socket file of the previous run is removed.
+func Unix(path string, mode fs.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+
+	own(l.(*net.UnixListener))
+
+	return l, nil
+}
+
+// Systemd returns the listeners passed by systemd socket activation, none when the process wasn't activated.
+// The variables are unset, so child processes don't take the sockets for their own.
+func Systemd() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv(envListenPID))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(os.Getenv(envListenFDs))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", envListenFDs, os.Getenv(envListenFDs))
+	}
+
+	names := strings.Split(os.Getenv(envListenFDNames), ":")
+
+	for _, env := range []string{envListenPID, envListenFDs, envListenFDNames} {
+		_ = os.Unsetenv(env)
+	}
+
+	return fromFDs(firstFD, n, names)
+}
+
+// fromFDs turns the inherited descriptors into listeners, the descriptors are closed as the listeners hold copies.
+func fromFDs(first, n int, names []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, n)
+
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("fd%d", first+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(first+i), name)
+
+		l, err := net.FileListener(f)
+		_ = f.Close()
+
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("listener %s: %w", name, err)
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, l := range listeners {
+		_ = l.Close()
+	}
+}
@@ -0,0 +1,114 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ihippik/template-service/config"
+)
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	listeners, err := Listen("127.0.0.1:0", config.SocketCfg{Path: path, Mode: "0600"})
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+
+	assert.Equal(t, "tcp", listeners[0].Addr().Network())
+	assert.Equal(t, "unix", listeners[1].Addr().Network())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	closeAll(listeners)
+
+	_, err = Listen("127.0.0.1:0", config.SocketCfg{Path: path, Mode: "rw"})
+	assert.EqualError(t, err, `socket mode: strconv.ParseUint: parsing "rw": invalid syntax`)
+}
+
+func TestUnix(t *testing.T) {
+	dir := t.TempDir()
+
+	stale := filepath.Join(dir, "stale.sock")
+
+	l, err := net.Listen("unix", stale)
+	require.NoError(t, err)
+
+	// the socket file is left behind as by a killed process.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+
+	l, err = Unix(stale, 0o660)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	regular := filepath.Join(dir, "regular")
+	require.NoError(t, os.WriteFile(regular, nil, 0o600))
+
+	_, err = Unix(regular, 0o660)
+	assert.EqualError(t, err, regular+" exists and is not a socket")
+}
+
+func TestSystemd(t *testing.T) {
+	t.Setenv(envListenPID, "1")
+	t.Setenv(envListenFDs, "1")
+
+	listeners, err := Systemd()
+	assert.NoError(t, err)
+	assert.Empty(t, listeners, "activated for another process")
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer tcp.Close()
+
+	f, err := tcp.(*net.TCPListener).File()
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	// fromFDs closes the descriptor, it must not be the one owned by f.
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+
+	listeners, err = fromFDs(fd, 1, []string{"http"})
+	require.NoError(t, err)
+	require.Len(t, listeners, 1)
+
+	defer listeners[0].Close()
+
+	assert.Equal(t, tcp.Addr().String(), listeners[0].Addr().String())
+}
+
+func TestListen_systemd(t *testing.T) {
+	activated, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer busy.Close()
+
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	// the configured address isn't listened on, it would fail as it's taken.
+	listeners, err := listen(busy.Addr().String(), config.SocketCfg{Path: path, Mode: "0600"}, []net.Listener{activated})
+	require.NoError(t, err)
+
+	defer closeAll(listeners)
+
+	require.Len(t, listeners, 2)
+	assert.Equal(t, activated.Addr().String(), listeners[0].Addr().String())
+	assert.Equal(t, path, listeners[1].Addr().String())
+}
@@ -0,0 +1,253 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// handoff variables set for the upgraded process, the listeners follow stdio as fd 3 and up
+// and the readiness pipe comes after them.
+const (
+	envInheritFDs   = "TEMPLATE_LISTEN_FDS"
+	envInheritNames = "TEMPLATE_LISTEN_FDNAMES"
+	envReadyFD      = "TEMPLATE_READY_FD"
+)
+
+// listener groups of the process.
+const (
+	Server = "server"
+	Admin  = "admin"
+)
+
+var (
+	inheritOnce sync.Once
+	inheritErr  error
+	inheritedBy map[string][]net.Listener
+
+	// owned are the unix sockets the process removes on shutdown, the ones of systemd are left to it.
+	owned sync.Map
+)
+
+// own makes the listener remove its unix socket file when closed.
+func own(l *net.UnixListener) {
+	l.SetUnlinkOnClose(true)
+	owned.Store(l, struct{}{})
+}
+
+// inherited returns the listeners of the group passed by the previous process on upgrade.
+func inherited(group string) ([]net.Listener, error) {
+	inheritOnce.Do(func() {
+		inheritedBy, inheritErr = inherit()
+	})
+
+	return inheritedBy[group], inheritErr
+}
+
+func inherit() (map[string][]net.Listener, error) {
+	raw, ok := os.LookupEnv(envInheritFDs)
+	if !ok {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", envInheritFDs, raw)
+	}
+
+	names := strings.Split(os.Getenv(envInheritNames), ":")
+
+	_ = os.Unsetenv(envInheritFDs)
+	_ = os.Unsetenv(envInheritNames)
+
+	listeners, err := fromFDs(firstFD, n, names)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]net.Listener)
+
+	for i, l := range listeners {
+		// unix sockets passed by the previous process are ours to remove on shutdown,
+		// unlike the ones of systemd, a further upgrade keeps them again.
+		if l, ok := l.(*net.UnixListener); ok {
+			own(l)
+		}
+
+		group := Server
+		if i < len(names) {
+			group = names[i]
+		}
+
+		groups[group] = append(groups[group], l)
+	}
+
+	return groups, nil
+}
+
+// ListenAdmin opens the admin listener or takes the one passed by the previous process.
+func ListenAdmin(addr string) (net.Listener, error) {
+	listeners, err := inherited(Admin)
+	if err != nil {
+		return nil, fmt.Errorf("inherit: %w", err)
+	}
+
+	if len(listeners) > 0 {
+		return listeners[0], nil
+	}
+
+	return net.Listen("tcp", addr)
+}
+
+// NotifyReady tells the previous process the upgraded one serves requests, so it can drain and exit.
+// It does nothing when the process wasn't started by Upgrade.
+func NotifyReady() error {
+	raw, ok := os.LookupEnv(envReadyFD)
+	if !ok {
+		return nil
+	}
+
+	_ = os.Unsetenv(envReadyFD)
+
+	fd, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", envReadyFD, raw)
+	}
+
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+
+	if _, err := f.Write([]byte{1}); err != nil {
+		return fmt.Errorf("notify ready: %w", err)
+	}
+
+	return nil
+}
+
+// Upgrade starts the new binary of the executable with the same arguments, passing the listeners by group,
+// and waits until it's ready. The listeners keep accepting connections all along,
+// on success the caller drains and exits, on failure the new process is killed.
+// The pid of the new process is returned.
+func Upgrade(ctx context.Context, groups map[string][]net.Listener, timeout time.Duration) (_ int, err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("executable: %w", err)
+	}
+
+	var (
+		files []*os.File
+		names []string
+	)
+
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	// the process keeps serving after a failed upgrade, so its unix sockets are removed on shutdown again.
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		for _, group := range groups {
+			for _, l := range group {
+				if l, ok := l.(*net.UnixListener); ok {
+					if _, ok := owned.Load(l); ok {
+						l.SetUnlinkOnClose(true)
+					}
+				}
+			}
+		}
+	}()
+
+	for _, group := range []string{Server, Admin} {
+		for _, l := range groups[group] {
+			f, err := file(l)
+			if err != nil {
+				return 0, fmt.Errorf("%s listener %s: %w", group, l.Addr(), err)
+			}
+
+			files = append(files, f)
+			names = append(names, group)
+		}
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("ready pipe: %w", err)
+	}
+
+	defer readyR.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(files, readyW)
+	cmd.Env = append(
+		os.Environ(),
+		envInheritFDs+"="+strconv.Itoa(len(files)),
+		envInheritNames+"="+strings.Join(names, ":"),
+		envReadyFD+"="+strconv.Itoa(firstFD+len(files)),
+	)
+
+	err = cmd.Start()
+	_ = readyW.Close()
+
+	if err != nil {
+		return 0, fmt.Errorf("start: %w", err)
+	}
+
+	exited := make(chan error, 1)
+
+	go func() { exited <- cmd.Wait() }()
+
+	ready := make(chan error, 1)
+
+	go func() {
+		// the pipe is closed without a byte when the new process exits early.
+		buf := make([]byte, 1)
+
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-ready:
+		if err == nil {
+			return cmd.Process.Pid, nil
+		}
+
+		return 0, fmt.Errorf("new process exited before ready: %w", <-exited)
+	case err := <-exited:
+		return 0, fmt.Errorf("new process exited before ready: %w", err)
+	case <-timer.C:
+		_ = cmd.Process.Kill()
+		return 0, fmt.Errorf("new process not ready within %s", timeout)
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		return 0, ctx.Err()
+	}
+}
+
+// file duplicates the descriptor of the listener, unix sockets stay on disk when the listener is closed.
+func file(l net.Listener) (*os.File, error) {
+	switch l := l.(type) {
+	case *net.TCPListener:
+		return l.File()
+	case *net.UnixListener:
+		l.SetUnlinkOnClose(false)
+		return l.File()
+	default:
+		return nil, fmt.Errorf("unsupported listener %T", l)
+	}
+}
@@ -0,0 +1,116 @@
+package listener
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ihippik/template-service/config"
+)
+
+// envHelper makes the test binary act as the upgraded process.
+const envHelper = "LISTENER_UPGRADE_HELPER"
+
+func TestMain(m *testing.M) {
+	if mode, ok := os.LookupEnv(envHelper); ok {
+		os.Exit(helper(mode))
+	}
+
+	os.Exit(m.Run())
+}
+
+// helper takes over the listeners and answers the first connection of each with its address.
+func helper(mode string) int {
+	if mode == "fail" {
+		return 1
+	}
+
+	server, err := Listen("", config.SocketCfg{})
+	if err != nil {
+		return 2
+	}
+
+	admin, err := ListenAdmin("")
+	if err != nil {
+		return 3
+	}
+
+	if err := NotifyReady(); err != nil {
+		return 4
+	}
+
+	for _, l := range []net.Listener{server[0], admin} {
+		conn, err := l.Accept()
+		if err != nil {
+			return 5
+		}
+
+		_, _ = conn.Write([]byte(l.Addr().String()))
+		_ = conn.Close()
+	}
+
+	return 0
+}
+
+func TestUpgrade(t *testing.T) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	admin, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	groups := map[string][]net.Listener{Server: {server}, Admin: {admin}}
+
+	t.Setenv(envHelper, "fail")
+
+	_, err = Upgrade(context.Background(), groups, 5*time.Second)
+	assert.ErrorContains(t, err, "new process exited before ready")
+
+	t.Setenv(envHelper, "ok")
+
+	pid, err := Upgrade(context.Background(), groups, 5*time.Second)
+	require.NoError(t, err)
+	assert.NotEqual(t, os.Getpid(), pid)
+
+	// the new process accepts on the same sockets after the old one closed them.
+	require.NoError(t, server.Close())
+	require.NoError(t, admin.Close())
+
+	for _, addr := range []string{server.Addr().String(), admin.Addr().String()} {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+		data, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		assert.Equal(t, addr, string(data))
+
+		_ = conn.Close()
+	}
+}
+
+func TestUpgrade_failedKeepsUnlink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	l, err := Unix(path, 0o660)
+	require.NoError(t, err)
+
+	t.Setenv(envHelper, "fail")
+
+	_, err = Upgrade(context.Background(), map[string][]net.Listener{Server: {l}}, 5*time.Second)
+	require.Error(t, err)
+
+	// the process keeps serving, so its shutdown removes the socket.
+	require.NoError(t, l.Close())
+
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
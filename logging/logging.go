@@ -0,0 +1,34 @@
+// Package logging carries the request-scoped logger in the context.
+package logging
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// WithLogger returns a copy of the context carrying the logger.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger stored in the context or the fallback when there is none,
+// the ids of the current span are added to link logs and traces.
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	logger, ok := ctx.Value(ctxKey{}).(*zap.Logger)
+	if !ok {
+		logger = fallback
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		logger = logger.With(
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	return logger
+}
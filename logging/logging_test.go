@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromContext(t *testing.T) {
+	fallback := zap.NewNop()
+	logger := zap.NewExample()
+
+	assert.Same(t, fallback, FromContext(context.Background(), fallback))
+	assert.Same(t, logger, FromContext(WithLogger(context.Background(), logger), fallback))
+}
+
+func TestFromContext_trace(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	FromContext(ctx, zap.New(core)).Info("test")
+
+	assert.Equal(t, map[string]any{
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+	}, logs.AllUntimed()[0].ContextMap())
+}
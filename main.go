@@ -2,18 +2,38 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 
+	"github.com/ihippik/template-service/apikey"
+	"github.com/ihippik/template-service/apperr"
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/blob"
 	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/fieldmask"
+	"github.com/ihippik/template-service/group"
+	"github.com/ihippik/template-service/health"
+	"github.com/ihippik/template-service/lifecycle"
+	"github.com/ihippik/template-service/listener"
+	"github.com/ihippik/template-service/middleware"
 	"github.com/ihippik/template-service/migrations"
+	"github.com/ihippik/template-service/policy"
+	"github.com/ihippik/template-service/ratelimit"
+	"github.com/ihippik/template-service/rbac"
+	"github.com/ihippik/template-service/tenant"
+	"github.com/ihippik/template-service/tlsconf"
+	"github.com/ihippik/template-service/tracing"
 	"github.com/ihippik/template-service/user"
 )
 
@@ -31,11 +51,48 @@ var gitVersion = "not_specified"
 // @tag.name Template-srv
 // @tag.description template service
 // @BasePath /v1
+//
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
 func main() {
 	app := &cli.App{
 		Name:  "Template service",
 		Usage: "template service",
+		Flags: configFlags(),
 		Commands: []*cli.Command{
+			{
+				Name:   "serve",
+				Usage:  "run the service",
+				Flags:  configFlags(),
+				Action: serve,
+			},
+			{
+				Name:  "config",
+				Usage: "configuration",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "print",
+						Usage: "print the effective configuration with secrets redacted",
+						Flags: configFlags(),
+						Action: func(c *cli.Context) error {
+							cfg, err := loadConfig(c)
+							if err != nil {
+								return err
+							}
+
+							enc := yaml.NewEncoder(c.App.Writer)
+							enc.SetIndent(2)
+
+							if err := enc.Encode(cfg.Redacted()); err != nil {
+								return err
+							}
+
+							return enc.Close()
+						},
+					},
+				},
+			},
 			{
 				Name:    "migrate",
 				Aliases: []string{"m"},
@@ -67,9 +124,7 @@ func main() {
 				},
 			},
 		},
-		Action: func(c *cli.Context) error {
-			return run(c.Context)
-		},
+		Action: serve,
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -77,56 +132,355 @@ func main() {
 	}
 }
 
-func run(mCtx context.Context) error {
-	ctx, cancel := signal.NotifyContext(mCtx, os.Interrupt)
+func serve(c *cli.Context) error {
+	src, err := configSources(c)
+	if err != nil {
+		return err
+	}
+
+	return run(c.Context, src)
+}
+
+func loadConfig(c *cli.Context) (*config.Config, error) {
+	src, err := configSources(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.New(c.Context, src)
+	if err != nil {
+		return nil, fmt.Errorf("could not int config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func run(mCtx context.Context, src config.Sources) (err error) {
+	ctx, cancel := signal.NotifyContext(mCtx, os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	cfg, err := config.New(ctx)
+	cfg, err := config.New(ctx, src)
 	if err != nil {
 		return fmt.Errorf("could not int config: %w", err)
 	}
 
-	logger, err := iniLogger(cfg.Log, gitVersion)
+	logger, level, err := iniLogger(cfg.Log, gitVersion)
 	if err != nil {
 		return fmt.Errorf("could not int logger: %w", err)
 	}
 
+	lc := lifecycle.New(logger, cfg.Shutdown.Timeout, cfg.Shutdown.Timeouts)
+
+	// the components started so far are stopped in reverse on return, also when the startup fails.
+	defer func() {
+		if stopErr := lc.Stop(context.WithoutCancel(mCtx)); stopErr != nil {
+			err = errors.Join(err, stopErr)
+		}
+	}()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, gitVersion)
+	if err != nil {
+		logger.Error("could`t init tracing", zap.Error(err))
+		return err
+	}
+
+	lc.Add(lifecycle.Component{Name: "tracing", Stop: shutdownTracing})
+
+	if err := apperr.Configure(cfg.Error); err != nil {
+		logger.Error("could`t init error format", zap.Error(err))
+		return err
+	}
+
 	db, err := initConn(cfg.DB)
 	if err != nil {
 		logger.Error("could`t init db connection", zap.Error(err))
 		return err
 	}
 
-	svc := user.NewService(cfg, logger, user.NewRepository(db))
-	endpts := user.NewEndpoint(logger, svc)
+	lc.Add(lifecycle.Component{Name: "db", Stop: func(context.Context) error { return db.Close() }})
+
+	if err := lc.Start(ctx); err != nil {
+		return err
+	}
+
+	checker := health.NewChecker(logger, cfg.Health.Timeout)
+	checker.Register("db", db.PingContext)
+	checker.Register("migrations", func(ctx context.Context) error {
+		return migrations.Check(ctx, db.DB)
+	})
+
+	storage, err := blob.NewLocal(cfg.Avatar.Dir)
+	if err != nil {
+		logger.Error("could`t init avatar storage", zap.Error(err))
+		return err
+	}
+
+	jwtAuth, err := auth.NewJWTAuthenticator(ctx, cfg.Auth)
+	if err != nil {
+		logger.Error("could`t init jwt authenticator", zap.Error(err))
+		return err
+	}
+
+	apiKeySvc := apikey.NewService(cfg, logger, apikey.NewRepository(db))
+	apiKeyEndpts := apikey.NewEndpoint(logger, apiKeySvc)
+
+	maskPolicy, err := fieldmask.Parse(cfg.Mask.Policy)
+	if err != nil {
+		logger.Error("could`t parse field masking policy", zap.Error(err))
+		return err
+	}
+
+	var policyAuthz policy.Authorizer
+
+	if cfg.Policy.File != "" {
+		engine, err := policy.NewEngine(logger, cfg.Policy.File)
+		if err != nil {
+			logger.Error("could`t load policy", zap.Error(err))
+			return err
+		}
+
+		lc.Add(lifecycle.Worker("policy", func(ctx context.Context) {
+			engine.Watch(ctx, cfg.Policy.ReloadInterval)
+		}))
+
+		policyAuthz = engine
+	}
+
+	rbacSvc := rbac.NewService(cfg, logger, rbac.NewRepository(db))
+	rbacEndpts := rbac.NewEndpoint(logger, rbacSvc)
+	authz := rbac.NewAuthorizer(logger, rbacSvc)
+
+	svc := user.NewService(cfg, logger, user.NewRepository(db), storage, policyAuthz)
+	endpts := user.NewEndpoint(logger, svc, maskPolicy)
+
+	groupSvc := group.NewService(cfg, logger, group.NewRepository(db))
+	groupEndpts := group.NewEndpoint(logger, groupSvc)
+
+	limiter, err := ratelimit.New(logger, cfg.RateLimit)
+	if err != nil {
+		logger.Error("could`t init rate limiter", zap.Error(err))
+		return err
+	}
+
+	lc.Add(lifecycle.Worker("ratelimit", func(ctx context.Context) {
+		limiter.Cleanup(ctx, time.Minute)
+	}))
+
+	reload := reloader{logger: logger, src: src, cfg: cfg, level: level, db: db, limiter: limiter}
+
+	lc.Add(lifecycle.Worker("reload", reload.Watch))
 
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /v1/users", endpts.ListUsers)
-	mux.HandleFunc("GET /v1/users/{id}", endpts.GetUser)
-	mux.HandleFunc("PUT /v1/users/{id}", endpts.UpdateUser)
-	mux.HandleFunc("POST /v1/users", endpts.CreateUser)
-	mux.HandleFunc("DELETE /v1/users/{id}", endpts.DeleteUser)
+	handle := func(pattern string, perm rbac.Permission, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, middleware.Route(pattern, limiter.Limit(pattern, authz.Require(perm, h))))
+	}
+
+	handle("GET /v1/users", rbac.UsersRead, endpts.ListUsers)
+	handle("GET /v1/users/{id}", rbac.UsersRead, endpts.GetUser)
+	handle("PUT /v1/users/{id}", rbac.UsersWrite, endpts.UpdateUser)
+	handle("POST /v1/users", rbac.UsersWrite, endpts.CreateUser)
+	handle("DELETE /v1/users/{id}", rbac.UsersDelete, endpts.DeleteUser)
+	handle("PUT /v1/users/{id}/labels", rbac.UsersWrite, endpts.UpdateLabels)
+	handle("PUT /v1/users/{id}/avatar", rbac.UsersWrite, endpts.UploadAvatar)
+	handle("GET /v1/users/{id}/avatar", rbac.UsersRead, endpts.GetAvatar)
+	handle("GET /v1/users/{id}/groups", rbac.GroupsRead, groupEndpts.ListUserGroups)
+
+	handle("GET /v1/groups", rbac.GroupsRead, groupEndpts.ListGroups)
+	handle("GET /v1/groups/{gid}", rbac.GroupsRead, groupEndpts.GetGroup)
+	handle("PUT /v1/groups/{gid}", rbac.GroupsWrite, groupEndpts.UpdateGroup)
+	handle("POST /v1/groups", rbac.GroupsWrite, groupEndpts.CreateGroup)
+	handle("DELETE /v1/groups/{gid}", rbac.GroupsDelete, groupEndpts.DeleteGroup)
+	handle("PUT /v1/groups/{gid}/members/{uid}", rbac.GroupsWrite, groupEndpts.AddMember)
+	handle("DELETE /v1/groups/{gid}/members/{uid}", rbac.GroupsWrite, groupEndpts.RemoveMember)
+
+	handle("GET /v1/roles", rbac.RoleBindingsManage, rbacEndpts.ListRoles)
+	handle("GET /v1/role-bindings", rbac.RoleBindingsManage, rbacEndpts.ListBindings)
+	handle("POST /v1/role-bindings", rbac.RoleBindingsManage, rbacEndpts.CreateBinding)
+	handle("DELETE /v1/role-bindings/{id}", rbac.RoleBindingsManage, rbacEndpts.DeleteBinding)
+
+	handle("GET /v1/api-keys", rbac.APIKeysManage, apiKeyEndpts.ListKeys)
+	handle("GET /v1/api-keys/{id}", rbac.APIKeysManage, apiKeyEndpts.GetKey)
+	handle("POST /v1/api-keys", rbac.APIKeysManage, apiKeyEndpts.CreateKey)
+	handle("DELETE /v1/api-keys/{id}", rbac.APIKeysManage, apiKeyEndpts.RevokeKey)
+
+	handler := tenant.Middleware(logger, auth.TenantFromPrincipal)(mux)
+	handler = auth.Middleware(logger, cfg.Auth.Realm, jwtAuth, apiKeySvc)(handler)
+	// the address limit guards the authentication lookups, the principal limits are per route inside.
+	handler = limiter.LimitIP(handler)
+	handler = middleware.Chain(
+		handler,
+		middleware.RequestID,
+		middleware.Tracing,
+		middleware.AccessLog(logger),
+		middleware.Metrics,
+		middleware.Recover(logger),
+		tlsconf.Middleware,
+	)
 
 	srv := http.Server{
 		Addr:              cfg.ServerAddr,
-		Handler:           mux,
+		Handler:           handler,
 		ReadHeaderTimeout: time.Second * 10,
 	}
 
-	go func() {
-		logger.Info("server was started", zap.String("addr", cfg.ServerAddr))
-
-		if err := srv.ListenAndServe(); err != nil {
-			logger.Error("listen & serve", zap.Error(err))
+	if cfg.TLS.CertFile != "" {
+		certs, err := tlsconf.NewReloader(logger, cfg.TLS)
+		if err != nil {
+			logger.Error("could`t init tls", zap.Error(err))
+			return err
 		}
-	}()
 
-	<-ctx.Done()
+		lc.Add(lifecycle.Worker("tls", func(ctx context.Context) {
+			certs.Watch(ctx, cfg.TLS.ReloadInterval)
+		}))
+
+		srv.TLSConfig = certs.Config()
+	}
+
+	if cfg.AdminToken == "" {
+		logger.Warn("admin token is not set, admin and pprof endpoints are disabled")
+	}
+
+	adminSrv := http.Server{
+		Addr:              cfg.AdminAddr,
+		Handler:           adminHandler(checker, level, cfg.AdminToken),
+		ReadHeaderTimeout: time.Second * 10,
+	}
+
+	listeners, err := listener.Listen(cfg.ServerAddr, cfg.Socket)
+	if err != nil {
+		logger.Error("could`t listen", zap.Error(err))
+		return err
+	}
+
+	adminListener, err := listener.ListenAdmin(cfg.AdminAddr)
+	if err != nil {
+		logger.Error("could`t listen admin", zap.Error(err))
+		return err
+	}
+
+	var upgraded bool
+
+	lc.Add(serverComponent(logger, "admin", &adminSrv, []net.Listener{adminListener}))
+	lc.Add(serverComponent(logger, "http", &srv, listeners))
+	lc.Add(lifecycle.Component{
+		Name: "drain",
+		Stop: func(ctx context.Context) error {
+			// the new process serves the same sockets after an upgrade, there is nothing to drain.
+			if upgraded {
+				return nil
+			}
 
-	if err := srv.Shutdown(mCtx); err != nil {
+			// probes fail first, so the load balancer stops routing before the listener closes.
+			checker.Shutdown()
+			logger.Info("draining traffic", zap.Duration("delay", cfg.Health.DrainDelay))
+
+			select {
+			case <-time.After(cfg.Health.DrainDelay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	if err := lc.Start(ctx); err != nil {
 		return err
 	}
 
+	if err := listener.NotifyReady(); err != nil {
+		logger.Error("could`t notify the previous process", zap.Error(err))
+	}
+
+	upgrades := make(chan os.Signal, 1)
+
+	signal.Notify(upgrades, syscall.SIGUSR2)
+	defer signal.Stop(upgrades)
+
+	upgraded = waitUpgrade(ctx, logger, upgrades, cfg.Upgrade.Timeout, map[string][]net.Listener{
+		listener.Server: listeners,
+		listener.Admin:  {adminListener},
+	})
+
 	return nil
 }
+
+// serverComponent serves the listeners, the stop waits for active requests and closes
+// the remaining connections when the timeout is over.
+func serverComponent(logger *zap.Logger, name string, srv *http.Server, listeners []net.Listener) lifecycle.Component {
+	return lifecycle.Component{
+		Name: name,
+		Start: func(context.Context) error {
+			for _, l := range listeners {
+				go func(l net.Listener) {
+					logger.Info(
+						"server was started",
+						zap.String("server", name),
+						zap.String("network", l.Addr().Network()),
+						zap.String("addr", l.Addr().String()),
+						zap.Bool("tls", servesTLS(srv, l)),
+					)
+
+					var err error
+
+					// certificates come from the config of the server.
+					if servesTLS(srv, l) {
+						err = srv.ServeTLS(l, "", "")
+					} else {
+						err = srv.Serve(l)
+					}
+
+					if err != nil && !errors.Is(err, http.ErrServerClosed) {
+						logger.Error("listen & serve", zap.String("server", name), zap.Error(err))
+					}
+				}(l)
+			}
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			err := srv.Shutdown(ctx)
+			if errors.Is(err, context.DeadlineExceeded) {
+				_ = srv.Close()
+			}
+
+			return err
+		},
+	}
+}
+
+// servesTLS reports whether the listener serves HTTPS, the unix socket stays plain HTTP
+// for the local proxy terminating TLS itself.
+func servesTLS(srv *http.Server, l net.Listener) bool {
+	return srv.TLSConfig != nil && l.Addr().Network() != "unix"
+}
+
+// waitUpgrade blocks until the context is done or the new binary took over the listeners on SIGUSR2,
+// a failed upgrade keeps the process serving.
+func waitUpgrade(
+	ctx context.Context,
+	logger *zap.Logger,
+	upgrades <-chan os.Signal,
+	timeout time.Duration,
+	listeners map[string][]net.Listener,
+) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-upgrades:
+			logger.Info("upgrading binary")
+
+			pid, err := listener.Upgrade(ctx, listeners, timeout)
+			if err != nil {
+				logger.Error("could`t upgrade, keep serving", zap.Error(err))
+				continue
+			}
+
+			logger.Info("new process is ready, shutting down", zap.Int("pid", pid))
+
+			return true
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package metrics collects Prometheus metrics of the service: HTTP requests,
+// repository queries, the database pool and the Go runtime.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "template"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route and status.",
+		},
+		[]string{"method", "route", "status"},
+	)
+	httpDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+	queryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of repository methods.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		},
+		[]string{"repository", "method"},
+	)
+	queryErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_errors_total",
+			Help:      "Number of failed repository methods.",
+		},
+		[]string{"repository", "method"},
+	)
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		queryDuration,
+		queryErrors,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exposes the connection pool stats of the database.
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRequest records the finished HTTP request.
+func ObserveRequest(method, route string, status int, latency time.Duration) {
+	code := strconv.Itoa(status)
+
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(latency.Seconds())
+}
+
+// ObserveQuery records the duration and the failure of the repository method,
+// it is deferred at the start of the method: defer metrics.ObserveQuery("user", "Get", time.Now(), &err).
+func ObserveQuery(repository, method string, start time.Time, err *error) {
+	queryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+
+	if err != nil && *err != nil {
+		queryErrors.WithLabelValues(repository, method).Inc()
+	}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveRequest(t *testing.T) {
+	ObserveRequest(http.MethodGet, "GET /v1/users/{id}", http.StatusNotFound, 20*time.Millisecond)
+	ObserveRequest(http.MethodGet, "GET /v1/users/{id}", http.StatusNotFound, 30*time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "GET /v1/users/{id}", "404")))
+	assert.Equal(t, 1, testutil.CollectAndCount(httpDuration, "template_http_request_duration_seconds"))
+}
+
+func TestObserveQuery(t *testing.T) {
+	observe := func(err error) {
+		defer ObserveQuery("user", "Get", time.Now(), &err)
+	}
+
+	observe(nil)
+	observe(errors.New("some err"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(queryErrors.WithLabelValues("user", "Get")))
+	assert.Equal(t, 1, testutil.CollectAndCount(queryDuration, "template_db_query_duration_seconds"))
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, string(data), "go_goroutines")
+}
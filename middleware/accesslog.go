@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/logging"
+)
+
+// unmatchedRoute is logged for requests not matched by any route.
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// route is filled by the matched handler to be seen by the access log.
+type route struct {
+	pattern string
+}
+
+// Route records the mux pattern of the handler for the access log.
+func Route(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+			rt.pattern = pattern
+		}
+
+		next(w, r)
+	}
+}
+
+// withRoute returns the request carrying the route holder, an existing holder is reused
+// so every middleware sees the pattern filled by Route.
+func withRoute(r *http.Request) (*http.Request, *route) {
+	if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return r, rt
+	}
+
+	rt := &route{pattern: unmatchedRoute}
+
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, rt)), rt
+}
+
+// routePattern returns the pattern of the route matched for the request.
+func routePattern(r *http.Request) string {
+	if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return rt.pattern
+	}
+
+	return unmatchedRoute
+}
+
+// AccessLog puts the request-scoped logger with the request id into the context
+// and logs every request when it is done.
+func AccessLog(logger *zap.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			reqLogger := logger
+			if id, ok := RequestIDFromContext(r.Context()); ok {
+				reqLogger = logger.With(zap.String("request_id", id))
+			}
+
+			r, rt := withRoute(r.WithContext(logging.WithLogger(r.Context(), reqLogger)))
+			rw := wrap(w)
+
+			next.ServeHTTP(rw, r)
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("route", rt.pattern),
+				zap.Int("status", rw.status),
+				zap.Int("size", rw.size),
+				zap.Duration("latency", time.Since(start)),
+			}
+
+			reqLogger = logging.FromContext(r.Context(), reqLogger)
+
+			if rw.status >= http.StatusInternalServerError {
+				reqLogger.Error("request", fields...)
+				return
+			}
+
+			reqLogger.Info("request", fields...)
+		})
+	}
+}
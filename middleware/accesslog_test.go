@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ihippik/template-service/logging"
+)
+
+func TestAccessLog(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/users", Route("GET /v1/users", func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context(), zap.NewNop()).Info("list users")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	mux.HandleFunc("/v1/groups", Route("GET /v1/groups", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	handler := Chain(mux, RequestID, AccessLog(zap.New(core)))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/groups", nil)
+	req.Header.Set(RequestIDHeader, "req-2")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/unknown", nil)
+	req.Header.Set(RequestIDHeader, "req-3")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 4)
+
+	assert.Equal(t, "list users", entries[0].Message)
+	assert.Equal(t, "req-1", entries[0].ContextMap()["request_id"])
+
+	type want struct {
+		level     zapcore.Level
+		requestID string
+		route     string
+		status    int64
+		size      int64
+	}
+
+	for i, w := range []want{
+		{level: zapcore.InfoLevel, requestID: "req-1", route: "GET /v1/users", status: http.StatusOK, size: 2},
+		{level: zapcore.ErrorLevel, requestID: "req-2", route: "GET /v1/groups", status: http.StatusBadGateway},
+		{level: zapcore.InfoLevel, requestID: "req-3", route: unmatchedRoute, status: http.StatusNotFound, size: 19},
+	} {
+		entry := entries[i+1]
+		fields := entry.ContextMap()
+
+		assert.Equal(t, "request", entry.Message)
+		assert.Equal(t, w.level, entry.Level)
+		assert.Equal(t, w.requestID, fields["request_id"])
+		assert.Equal(t, http.MethodGet, fields["method"])
+		assert.Equal(t, w.route, fields["route"])
+		assert.Equal(t, w.status, fields["status"])
+		assert.Equal(t, w.size, fields["size"])
+		assert.Contains(t, fields, "latency")
+	}
+}
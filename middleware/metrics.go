@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ihippik/template-service/metrics"
+)
+
+// Metrics records the request count and latency by route and status,
+// it must be inside AccessLog which tracks the matched route.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := wrap(w)
+
+		next.ServeHTTP(rw, r)
+
+		metrics.ObserveRequest(r.Method, routePattern(r), rw.status, time.Since(start))
+	})
+}
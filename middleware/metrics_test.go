@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/groups", Route("POST /v1/groups", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler := Chain(mux, AccessLog(zap.NewNop()), Metrics)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/groups", nil))
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	data, err := io.ReadAll(w.Result().Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `template_http_requests_total{method="POST",route="POST /v1/groups",status="201"} 1`)
+}
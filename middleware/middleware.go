@@ -0,0 +1,17 @@
+// Package middleware provides the HTTP middleware chain shared by all routes:
+// request ids, access logs and panic recovery.
+package middleware
+
+import "net/http"
+
+// Middleware wraps the handler.
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies middlewares to the handler, the first one is the outermost.
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+	"github.com/ihippik/template-service/logging"
+)
+
+// Recover turns a handler panic into the 500 ServiceError, the panic is logged with the stack trace.
+func Recover(logger *zap.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := wrap(w)
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logging.FromContext(r.Context(), logger).Error(
+					"handler panic",
+					zap.Any("panic", rec),
+					zap.StackSkip("stack", 1),
+				)
+
+				if rw.wroteHeader {
+					return
+				}
+
+				apperr.Write(rw, r, logger, fmt.Errorf("panic: %v", rec))
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		wantHTTPCode int
+		want         string
+		wantLogs     int
+	}{
+		{
+			name: "no panic",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         "ok",
+		},
+		{
+			name: "panic",
+			handler: func(_ http.ResponseWriter, _ *http.Request) {
+				panic("boom")
+			},
+			wantHTTPCode: http.StatusInternalServerError,
+			want:         `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users","code":"INTERNAL_SERVER_ERROR"}`,
+			wantLogs:     1,
+		},
+		{
+			name: "panic after write",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("boom")
+			},
+			wantHTTPCode: http.StatusAccepted,
+			want:         "",
+			wantLogs:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.ErrorLevel)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			w := httptest.NewRecorder()
+
+			Recover(zap.New(core))(tt.handler).ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+			assert.Equal(t, tt.wantLogs, logs.FilterMessage("handler panic").Len())
+		})
+	}
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		abort := func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) }
+		Recover(zap.NewNop())(http.HandlerFunc(abort)).
+			ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
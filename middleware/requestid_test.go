@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantKeep bool
+	}{
+		{
+			name:     "propagated",
+			header:   "req-42",
+			wantKeep: true,
+		},
+		{
+			name:   "missing",
+			header: "",
+		},
+		{
+			name:   "too long",
+			header: strings.Repeat("a", maxRequestIDLen+1),
+		},
+		{
+			name:   "control characters",
+			header: "req\t42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+
+			next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got, _ = RequestIDFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			req.Header.Set(RequestIDHeader, tt.header)
+
+			w := httptest.NewRecorder()
+
+			RequestID(next).ServeHTTP(w, req)
+
+			assert.Equal(t, got, w.Header().Get(RequestIDHeader))
+
+			if tt.wantKeep {
+				assert.Equal(t, tt.header, got)
+				return
+			}
+
+			_, err := uuid.Parse(got)
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ihippik/template-service/tracing"
+)
+
+// Tracing continues the trace of the W3C traceparent header in the server span of the request,
+// the span is named after the matched route.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := otel.Tracer(tracing.InstrumentationName).Start(
+			ctx,
+			r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		r, rt := withRoute(r.WithContext(ctx))
+		rw := wrap(w)
+
+		next.ServeHTTP(rw, r)
+
+		if rt.pattern != unmatchedRoute {
+			span.SetName(rt.pattern)
+			span.SetAttributes(semconv.HTTPRoute(rt.pattern))
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rw.status))
+
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+		}
+	})
+}
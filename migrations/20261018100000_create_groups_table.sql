@@ -0,0 +1,20 @@
+-- +goose Up
+create table groups
+(
+    id          uuid
+        constraint pk_groups_id
+            primary key,
+    parent_id   uuid
+        constraint fk_groups_parent_id
+            references groups (id)
+            on delete set null,
+    name        text      not null,
+    description text      not null default '',
+    created_at  timestamp not null,
+    updated_at  timestamp
+);
+
+create index idx_groups_parent_id on groups (parent_id);
+
+-- +goose Down
+drop table groups;
@@ -0,0 +1,20 @@
+-- +goose Up
+create table group_members
+(
+    group_id   uuid      not null
+        constraint fk_group_members_group_id
+            references groups (id)
+            on delete cascade,
+    user_id    uuid      not null
+        constraint fk_group_members_user_id
+            references users (id)
+            on delete cascade,
+    created_at timestamp not null,
+    constraint pk_group_members
+        primary key (group_id, user_id)
+);
+
+create index idx_group_members_user_id on group_members (user_id);
+
+-- +goose Down
+drop table group_members;
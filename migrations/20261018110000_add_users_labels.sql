@@ -0,0 +1,11 @@
+-- +goose Up
+alter table users
+    add column labels jsonb not null default '{}'::jsonb;
+
+create index idx_users_labels on users using gin (labels);
+
+-- +goose Down
+drop index idx_users_labels;
+
+alter table users
+    drop column labels;
@@ -0,0 +1,7 @@
+-- +goose Up
+alter table users
+    add column avatar_updated_at timestamp;
+
+-- +goose Down
+alter table users
+    drop column avatar_updated_at;
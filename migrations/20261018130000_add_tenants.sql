@@ -0,0 +1,53 @@
+-- +goose Up
+-- Existing rows are assigned to the nil tenant, new rows take the tenant of the current transaction.
+-- Row-level security is not applied to superusers, the service must connect as an ordinary role.
+alter table users
+    add column tenant_id uuid not null default '00000000-0000-0000-0000-000000000000';
+alter table users
+    alter column tenant_id set default nullif(current_setting('app.tenant_id', true), '')::uuid;
+create index idx_users_tenant_id on users (tenant_id);
+
+alter table groups
+    add column tenant_id uuid not null default '00000000-0000-0000-0000-000000000000';
+alter table groups
+    alter column tenant_id set default nullif(current_setting('app.tenant_id', true), '')::uuid;
+create index idx_groups_tenant_id on groups (tenant_id);
+
+alter table group_members
+    add column tenant_id uuid not null default '00000000-0000-0000-0000-000000000000';
+alter table group_members
+    alter column tenant_id set default nullif(current_setting('app.tenant_id', true), '')::uuid;
+
+alter table users enable row level security;
+alter table users force row level security;
+create policy tenant_isolation on users
+    using (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid)
+    with check (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+alter table groups enable row level security;
+alter table groups force row level security;
+create policy tenant_isolation on groups
+    using (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid)
+    with check (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+alter table group_members enable row level security;
+alter table group_members force row level security;
+create policy tenant_isolation on group_members
+    using (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid)
+    with check (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+-- +goose Down
+drop policy tenant_isolation on group_members;
+alter table group_members disable row level security;
+alter table group_members no force row level security;
+alter table group_members drop column tenant_id;
+
+drop policy tenant_isolation on groups;
+alter table groups disable row level security;
+alter table groups no force row level security;
+alter table groups drop column tenant_id;
+
+drop policy tenant_isolation on users;
+alter table users disable row level security;
+alter table users no force row level security;
+alter table users drop column tenant_id;
@@ -0,0 +1,27 @@
+-- +goose Up
+create table api_keys
+(
+    id           uuid
+        constraint pk_api_keys_id
+            primary key,
+    tenant_id    uuid      not null default nullif(current_setting('app.tenant_id', true), '')::uuid,
+    name         text      not null,
+    scopes       text[]    not null default '{}',
+    hash         bytea     not null,
+    salt         bytea     not null,
+    expires_at   timestamp,
+    last_used_at timestamp,
+    revoked_at   timestamp,
+    created_at   timestamp not null
+);
+
+create index idx_api_keys_tenant_id on api_keys (tenant_id);
+
+alter table api_keys enable row level security;
+alter table api_keys force row level security;
+create policy tenant_isolation on api_keys
+    using (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid)
+    with check (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+-- +goose Down
+drop table api_keys;
@@ -0,0 +1,22 @@
+-- +goose Up
+create table role_bindings
+(
+    id         uuid
+        constraint pk_role_bindings_id
+            primary key,
+    tenant_id  uuid      not null default nullif(current_setting('app.tenant_id', true), '')::uuid,
+    subject    text      not null,
+    role       text      not null,
+    created_at timestamp not null,
+    constraint uq_role_bindings_subject_role
+        unique (tenant_id, subject, role)
+);
+
+alter table role_bindings enable row level security;
+alter table role_bindings force row level security;
+create policy tenant_isolation on role_bindings
+    using (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid)
+    with check (tenant_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+-- +goose Down
+drop table role_bindings;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Foreign keys are checked bypassing row-level security, the tenant is part of the keys,
+-- so a membership can't reference a user or a group of another tenant.
+alter table users
+    add constraint uq_users_tenant_id_id unique (tenant_id, id);
+alter table groups
+    add constraint uq_groups_tenant_id_id unique (tenant_id, id);
+
+alter table group_members
+    drop constraint fk_group_members_user_id,
+    drop constraint fk_group_members_group_id,
+    add constraint fk_group_members_user_id
+        foreign key (tenant_id, user_id) references users (tenant_id, id) on delete cascade,
+    add constraint fk_group_members_group_id
+        foreign key (tenant_id, group_id) references groups (tenant_id, id) on delete cascade;
+
+-- +goose Down
+alter table group_members
+    drop constraint fk_group_members_user_id,
+    drop constraint fk_group_members_group_id,
+    add constraint fk_group_members_user_id
+        foreign key (user_id) references users (id) on delete cascade,
+    add constraint fk_group_members_group_id
+        foreign key (group_id) references groups (id) on delete cascade;
+
+alter table groups
+    drop constraint uq_groups_tenant_id_id;
+alter table users
+    drop constraint uq_users_tenant_id_id;
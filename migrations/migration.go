@@ -1,8 +1,11 @@
 package migrations
 
 import (
+	"context"
+	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -48,3 +51,90 @@ func Down(conn string) error {
 
 	return nil
 }
+
+// Latest returns the version of the newest embedded migration.
+func Latest() (int64, error) {
+	files, err := fs.Glob(embedMigrations, "*.sql")
+	if err != nil {
+		return 0, fmt.Errorf("list migrations: %w", err)
+	}
+
+	var latest int64
+
+	for _, f := range files {
+		v, err := goose.NumericComponent(f)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", f, err)
+		}
+
+		if v > latest {
+			latest = v
+		}
+	}
+
+	return latest, nil
+}
+
+// Version returns the current version of the database schema, it doesn't create the version table
+// as goose.GetDBVersion does.
+func Version(ctx context.Context, db *sql.DB) (int64, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		"SELECT version_id, is_applied FROM "+goose.TableName()+" ORDER BY id DESC",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("query versions: %w", err)
+	}
+
+	defer rows.Close()
+
+	// the newest record of the version decides, rolled back versions are skipped.
+	seen := make(map[int64]struct{})
+
+	for rows.Next() {
+		var (
+			version int64
+			applied bool
+		)
+
+		if err := rows.Scan(&version, &applied); err != nil {
+			return 0, fmt.Errorf("scan version: %w", err)
+		}
+
+		if _, ok := seen[version]; ok {
+			continue
+		}
+
+		seen[version] = struct{}{}
+
+		if applied {
+			return version, nil
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("read versions: %w", err)
+	}
+
+	return 0, nil
+}
+
+// Check fails when the database schema is behind the latest embedded migration. A schema ahead of it
+// is fine: during a rolling deploy the new release migrates while the old one still serves.
+func Check(ctx context.Context, db *sql.DB) error {
+	latest, err := Latest()
+	if err != nil {
+		return err
+	}
+
+	current, err := Version(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if current < latest {
+		return fmt.Errorf("schema version %d, expected at least %d", current, latest)
+	}
+
+	return nil
+}
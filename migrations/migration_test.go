@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatest(t *testing.T) {
+	latest, err := Latest()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20261018160000), latest)
+}
+
+func TestCheck(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	const query = `SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC`
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				mock.ExpectQuery(query).WillReturnRows(
+					sqlmock.NewRows([]string{"version_id", "is_applied"}).
+						AddRow(20261018160000, true).
+						AddRow(20261018150000, true),
+				)
+			},
+		},
+		{
+			name: "rolled back",
+			setup: func() {
+				mock.ExpectQuery(query).WillReturnRows(
+					sqlmock.NewRows([]string{"version_id", "is_applied"}).
+						AddRow(20261018160000, false).
+						AddRow(20261018160000, true).
+						AddRow(20261018150000, true),
+				)
+			},
+			wantErr: errors.New("schema version 20261018150000, expected at least 20261018160000"),
+		},
+		{
+			name: "ahead",
+			setup: func() {
+				mock.ExpectQuery(query).WillReturnRows(
+					sqlmock.NewRows([]string{"version_id", "is_applied"}).
+						AddRow(20261018170000, true).
+						AddRow(20261018160000, true),
+				)
+			},
+		},
+		{
+			name: "query err",
+			setup: func() {
+				mock.ExpectQuery(query).WillReturnError(errors.New("some err"))
+			},
+			wantErr: errors.New("query versions: some err"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+
+			err := Check(context.Background(), mockDB)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
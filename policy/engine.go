@@ -0,0 +1,213 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/cel-go/cel"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/rbac"
+	"github.com/ihippik/template-service/tenant"
+)
+
+// Engine evaluates the rules of the policy file, the file is reloaded when it changes.
+type Engine struct {
+	logger *zap.Logger
+	path   string
+	env    *cel.Env
+
+	mu      sync.RWMutex
+	rules   []compiledRule
+	modTime time.Time
+}
+
+// NewEngine creates new Engine and loads the policy file.
+func NewEngine(logger *zap.Logger, path string) (*Engine, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, fmt.Errorf("cel env: %w", err)
+	}
+
+	e := &Engine{logger: logger, path: path, env: env}
+
+	if _, err := e.reload(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Watch reloads the policy file when its modification time changes until the context is done.
+// An invalid file is logged and the previous rules stay in effect.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := e.reload()
+			if err != nil {
+				e.logger.Error("could not reload policy, keeping the previous one", zap.String("path", e.path), zap.Error(err))
+				continue
+			}
+
+			if reloaded {
+				e.logger.Info("policy was reloaded", zap.String("path", e.path))
+			}
+		}
+	}
+}
+
+func (e *Engine) reload() (bool, error) {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return false, fmt.Errorf("stat: %w", err)
+	}
+
+	e.mu.RLock()
+	unchanged := info.ModTime().Equal(e.modTime)
+	e.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	rules, err := load(e.env, e.path)
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	e.rules = rules
+	e.modTime = info.ModTime()
+	e.mu.Unlock()
+
+	return true, nil
+}
+
+// Authorize evaluates the rules for the action of the caller from the context,
+// the error wraps ErrDenied when the operation is not allowed.
+func (e *Engine) Authorize(ctx context.Context, action string, resource, input any) error {
+	vars, err := variables(ctx, action, resource, input)
+	if err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	var allowed bool
+
+	for _, rule := range rules {
+		if !rule.matches(action) {
+			continue
+		}
+
+		out, _, err := rule.prg.ContextEval(ctx, vars)
+		if err != nil {
+			// fail closed, a rule that can not be evaluated must not let the caller in.
+			return fmt.Errorf("%w: rule %s: %v", ErrDenied, rule.Name, err)
+		}
+
+		if out.Value() != true {
+			continue
+		}
+
+		if rule.Effect == Deny {
+			return fmt.Errorf("%w: rule %s", ErrDenied, rule.Name)
+		}
+
+		allowed = true
+	}
+
+	if !allowed {
+		return fmt.Errorf("%w: no rule allows %s", ErrDenied, action)
+	}
+
+	return nil
+}
+
+func variables(ctx context.Context, action string, resource, input any) (map[string]any, error) {
+	principal := map[string]any{
+		"subject": "",
+		"method":  "",
+		"roles":   []string{},
+		"scopes":  []string{},
+		"tenant":  "",
+	}
+
+	if p, ok := auth.FromContext(ctx); ok {
+		principal["subject"] = p.Subject
+		principal["method"] = p.Method
+
+		if p.Scopes != nil {
+			principal["scopes"] = p.Scopes
+		}
+
+		if p.TenantID != nil {
+			principal["tenant"] = p.TenantID.String()
+		}
+	}
+
+	if roles, ok := rbac.RolesFromContext(ctx); ok && roles != nil {
+		principal["roles"] = roles
+	}
+
+	var tenantID string
+
+	if id, ok := tenant.FromContext(ctx); ok {
+		tenantID = id.String()
+	}
+
+	resourceMap, err := toMap(resource)
+	if err != nil {
+		return nil, fmt.Errorf("resource: %w", err)
+	}
+
+	inputMap, err := toMap(input)
+	if err != nil {
+		return nil, fmt.Errorf("input: %w", err)
+	}
+
+	return map[string]any{
+		"principal": principal,
+		"action":    action,
+		"tenant":    tenantID,
+		"resource":  resourceMap,
+		"input":     inputMap,
+	}, nil
+}
+
+// toMap converts the entity to a map keyed by its JSON field names.
+func toMap(v any) (map[string]any, error) {
+	res := make(map[string]any)
+
+	if v == nil {
+		return res, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		res = make(map[string]any)
+	}
+
+	return res, nil
+}
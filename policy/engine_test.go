@@ -0,0 +1,224 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/rbac"
+	"github.com/ihippik/template-service/tenant"
+)
+
+const testPolicy = `
+rules:
+  - name: viewers-read
+    effect: allow
+    actions: ["users:get", "users:list"]
+    condition: '"viewer" in principal.roles'
+  - name: editors-update-own-tenant
+    effect: allow
+    actions: ["users:update"]
+    condition: >
+      "editor" in principal.roles && principal.tenant == tenant &&
+      input.birthday == resource.birthday
+  - name: nobody-touches-founders
+    effect: deny
+    actions: ["*"]
+    condition: 'has(resource.labels) && resource.labels.role == "founder"'
+  - name: broken
+    effect: allow
+    actions: ["users:delete"]
+    condition: 'resource.missing == "x"'
+`
+
+type target struct {
+	ID       string            `json:"id"`
+	Birthday string            `json:"birthday"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+func writePolicy(t *testing.T, path, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestEngine_Authorize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writePolicy(t, path, testPolicy)
+
+	e, err := NewEngine(zap.NewNop(), path)
+	require.NoError(t, err)
+
+	tenantID := uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d")
+
+	caller := func(tenantClaim uuid.UUID, roles ...string) context.Context {
+		ctx := tenant.WithID(context.Background(), tenantID)
+		ctx = rbac.WithRoles(ctx, roles)
+
+		return auth.WithPrincipal(ctx, &auth.Principal{Subject: "alice", TenantID: &tenantClaim})
+	}
+
+	user := &target{ID: "1", Birthday: "1971-06-28"}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		action   string
+		resource any
+		input    any
+		wantErr  bool
+	}{
+		{
+			name:     "viewer reads",
+			ctx:      caller(tenantID, "viewer"),
+			action:   "users:get",
+			resource: user,
+		},
+		{
+			name:   "viewer lists without resource",
+			ctx:    caller(tenantID, "viewer"),
+			action: "users:list",
+		},
+		{
+			name:     "viewer can not update",
+			ctx:      caller(tenantID, "viewer"),
+			action:   "users:update",
+			resource: user,
+			input:    map[string]any{"birthday": "1971-06-28"},
+			wantErr:  true,
+		},
+		{
+			name:     "editor updates",
+			ctx:      caller(tenantID, "editor"),
+			action:   "users:update",
+			resource: user,
+			input:    map[string]any{"birthday": "1971-06-28"},
+		},
+		{
+			name:     "editor can not change birthday",
+			ctx:      caller(tenantID, "editor"),
+			action:   "users:update",
+			resource: user,
+			input:    map[string]any{"birthday": "1980-01-01"},
+			wantErr:  true,
+		},
+		{
+			name:     "editor of another tenant",
+			ctx:      caller(uuid.New(), "editor"),
+			action:   "users:update",
+			resource: user,
+			input:    map[string]any{"birthday": "1971-06-28"},
+			wantErr:  true,
+		},
+		{
+			name:     "deny wins",
+			ctx:      caller(tenantID, "viewer"),
+			action:   "users:get",
+			resource: &target{ID: "2", Labels: map[string]string{"role": "founder"}},
+			wantErr:  true,
+		},
+		{
+			name:     "evaluation error fails closed",
+			ctx:      caller(tenantID, "admin"),
+			action:   "users:delete",
+			resource: user,
+			wantErr:  true,
+		},
+		{
+			name:     "anonymous",
+			ctx:      context.Background(),
+			action:   "users:get",
+			resource: user,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e.Authorize(tt.ctx, tt.action, tt.resource, tt.input)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrDenied), err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestEngine_reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writePolicy(t, path, testPolicy)
+
+	e, err := NewEngine(zap.NewNop(), path)
+	require.NoError(t, err)
+
+	ctx := rbac.WithRoles(context.Background(), []string{"viewer"})
+
+	// unchanged file is not reloaded.
+	reloaded, err := e.reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// invalid file keeps the previous rules.
+	writePolicy(t, path, `rules: [{name: bad, effect: allow, actions: ["*"], condition: "1 +"}]`)
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+
+	_, err = e.reload()
+	assert.Error(t, err)
+	assert.NoError(t, e.Authorize(ctx, "users:get", nil, nil))
+
+	writePolicy(t, path, `rules: [{name: nobody, effect: deny, actions: ["*"]}]`)
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+
+	reloaded, err = e.reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.ErrorIs(t, e.Authorize(ctx, "users:get", nil, nil), ErrDenied)
+}
+
+func TestLoad(t *testing.T) {
+	env, err := newEnv()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "unknown effect",
+			data:    `rules: [{name: r, effect: maybe, actions: ["*"]}]`,
+			wantErr: `rule r: unknown effect "maybe"`,
+		},
+		{
+			name:    "no actions",
+			data:    `rules: [{name: r, effect: allow}]`,
+			wantErr: "rule r: no actions",
+		},
+		{
+			name:    "not bool",
+			data:    `rules: [{effect: allow, actions: ["*"], condition: "action"}]`,
+			wantErr: "rule #0: condition must be bool, got string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			writePolicy(t, path, tt.data)
+
+			_, err := load(env, path)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package policy authorizes operations with CEL rules loaded from a policy file.
+//
+// A policy file lists rules:
+//
+//	rules:
+//	  - name: editors-update-own-tenant
+//	    effect: allow
+//	    actions: ["users:update"]
+//	    condition: >
+//	      "editor" in principal.roles && principal.tenant == tenant &&
+//	      input.birthday == resource.birthday
+//
+// The operation is allowed when an allow rule matches and no deny rule does.
+// Conditions see principal (subject, method, roles, scopes, tenant), action,
+// tenant, resource (the target entity) and input (the request data) by their JSON field names.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/cel-go/cel"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrDenied is returned when the policy does not allow the operation.
+var ErrDenied = errors.New("denied by policy")
+
+// Effect of the matched rule.
+type Effect string
+
+// rule effects.
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// AnyAction matches every action.
+const AnyAction = "*"
+
+// Rule grants or denies actions when the condition evaluates to true, an empty condition is always true.
+type Rule struct {
+	Name      string   `yaml:"name"`
+	Effect    Effect   `yaml:"effect"`
+	Actions   []string `yaml:"actions"`
+	Condition string   `yaml:"condition"`
+}
+
+type document struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+type compiledRule struct {
+	Rule
+	prg cel.Program
+}
+
+func (r compiledRule) matches(action string) bool {
+	for _, a := range r.Actions {
+		if a == action || a == AnyAction {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("principal", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("action", cel.StringType),
+		cel.Variable("tenant", cel.StringType),
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("input", cel.MapType(cel.StringType, cel.DynType)),
+	)
+}
+
+// load reads and compiles the policy file, any invalid rule fails the whole file.
+func load(env *cel.Env, path string) ([]compiledRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	var doc document
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	rules := make([]compiledRule, 0, len(doc.Rules))
+
+	for i, rule := range doc.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("#%d", i)
+		}
+
+		compiled, err := compile(env, rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+
+		rules = append(rules, compiled)
+	}
+
+	return rules, nil
+}
+
+func compile(env *cel.Env, rule Rule) (compiledRule, error) {
+	if rule.Effect != Allow && rule.Effect != Deny {
+		return compiledRule{}, fmt.Errorf("unknown effect %q", rule.Effect)
+	}
+
+	if len(rule.Actions) == 0 {
+		return compiledRule{}, errors.New("no actions")
+	}
+
+	condition := rule.Condition
+	if condition == "" {
+		condition = "true"
+	}
+
+	ast, iss := env.Compile(condition)
+	if iss.Err() != nil {
+		return compiledRule{}, iss.Err()
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return compiledRule{}, fmt.Errorf("condition must be bool, got %s", ast.OutputType())
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return compiledRule{}, err
+	}
+
+	return compiledRule{Rule: rule, prg: prg}, nil
+}
+
+// Authorizer decides whether the caller from the context may perform the action
+// on the resource with the input.
+type Authorizer interface {
+	Authorize(ctx context.Context, action string, resource, input any) error
+}
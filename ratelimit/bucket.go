@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, the bucket holds up to Requests tokens
+// and is refilled evenly over the Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses the "<requests>/<period>" limit, e.g. "100/1m".
+func ParseLimit(raw string) (Limit, error) {
+	rawReq, rawPeriod, ok := strings.Cut(raw, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q must be <requests>/<period>", raw)
+	}
+
+	requests, err := strconv.Atoi(rawReq)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: requests must be a positive number", raw)
+	}
+
+	period, err := time.ParseDuration(rawPeriod)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: period must be a positive duration", raw)
+	}
+
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// String implements fmt.Stringer interface.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// state of the bucket after taking a token.
+type state struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+func newBucket(limit Limit, now time.Time) *bucket {
+	return &bucket{tokens: float64(limit.Requests), last: now}
+}
+
+// take refills the bucket for the elapsed time and takes a token when there is one.
+func (b *bucket) take(limit Limit, now time.Time) state {
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	var st state
+
+	if b.tokens >= 1 {
+		b.tokens--
+		st.allowed = true
+	} else {
+		st.retryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	st.remaining = int(b.tokens)
+	st.reset = seconds((capacity - b.tokens) / rate)
+
+	return st
+}
+
+// full reports whether the bucket would be full at the time, such bucket can be dropped.
+func (b *bucket) full(limit Limit, now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*limit.rate() >= float64(limit.Requests)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
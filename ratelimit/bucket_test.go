@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Limit
+		wantErr string
+	}{
+		{
+			name: "success",
+			raw:  "100/1m",
+			want: Limit{Requests: 100, Period: time.Minute},
+		},
+		{
+			name:    "no period",
+			raw:     "100",
+			wantErr: `limit "100" must be <requests>/<period>`,
+		},
+		{
+			name:    "invalid requests",
+			raw:     "0/1m",
+			wantErr: `limit "0/1m": requests must be a positive number`,
+		},
+		{
+			name:    "invalid period",
+			raw:     "10/minute",
+			wantErr: `limit "10/minute": period must be a positive duration`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimit(tt.raw)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBucket_take(t *testing.T) {
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	b := newBucket(limit, now)
+
+	assert.Equal(t, state{allowed: true, remaining: 1, reset: time.Second}, b.take(limit, now))
+	assert.Equal(t, state{allowed: true, remaining: 0, reset: 2 * time.Second}, b.take(limit, now))
+	assert.Equal(
+		t,
+		state{allowed: false, remaining: 0, reset: 2 * time.Second, retryAfter: time.Second},
+		b.take(limit, now),
+	)
+
+	// half a token is refilled.
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(
+		t,
+		state{allowed: false, remaining: 0, reset: 1500 * time.Millisecond, retryAfter: 500 * time.Millisecond},
+		b.take(limit, now),
+	)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, state{allowed: true, remaining: 0, reset: 2 * time.Second}, b.take(limit, now))
+
+	assert.False(t, b.full(limit, now.Add(time.Second)))
+	assert.True(t, b.full(limit, now.Add(2*time.Second)))
+}
@@ -0,0 +1,237 @@
+// Package ratelimit limits requests of every client with token buckets.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/config"
+)
+
+// RateLimited is the service error code.
+const RateLimited = "RATE_LIMITED"
+
+type entry struct {
+	bucket *bucket
+	limit  Limit
+}
+
+// Limiter keeps a bucket per route and client, the client is identified by
+// the API key, the JWT subject or the IP address. The IP limit has a bucket per address for all routes.
+type Limiter struct {
+	logger     *zap.Logger
+	trustProxy bool
+	now        func() time.Time
+
+	limitsMu sync.RWMutex
+	def      Limit
+	ip       *Limit
+	routes   map[string]Limit
+
+	mu      sync.Mutex
+	buckets map[string]entry
+}
+
+// New creates new Limiter from the config.
+func New(logger *zap.Logger, cfg config.RateLimitCfg) (*Limiter, error) {
+	l := &Limiter{
+		logger:     logger,
+		trustProxy: cfg.TrustProxy,
+		now:        time.Now,
+		buckets:    make(map[string]entry),
+	}
+
+	if err := l.Reload(cfg); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Reload replaces the default and route limits, an invalid config keeps the current limits.
+// Existing buckets are refilled at the new rate up to the new capacity.
+func (l *Limiter) Reload(cfg config.RateLimitCfg) error {
+	def, err := ParseLimit(cfg.Default)
+	if err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+
+	var ip *Limit
+
+	if cfg.IP != "" {
+		limit, err := ParseLimit(cfg.IP)
+		if err != nil {
+			return fmt.Errorf("ip: %w", err)
+		}
+
+		ip = &limit
+	}
+
+	routes := make(map[string]Limit, len(cfg.Routes))
+
+	for route, raw := range cfg.Routes {
+		limit, err := ParseLimit(raw)
+		if err != nil {
+			return fmt.Errorf("route %s: %w", route, err)
+		}
+
+		routes[route] = limit
+	}
+
+	l.limitsMu.Lock()
+	l.def = def
+	l.ip = ip
+	l.routes = routes
+	l.limitsMu.Unlock()
+
+	return nil
+}
+
+func (l *Limiter) limit(route string) Limit {
+	l.limitsMu.RLock()
+	defer l.limitsMu.RUnlock()
+
+	if limit, ok := l.routes[route]; ok {
+		return limit
+	}
+
+	return l.def
+}
+
+// Limit wraps the handler of the route pattern, requests over the limit are rejected with 429.
+func (l *Limiter) Limit(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l.serve(w, r, route, l.limit(route), l.client(r), next)
+	}
+}
+
+// LimitIP wraps the handler with the limit per client address. It goes before the authentication,
+// so requests with invalid credentials are limited as well.
+func (l *Limiter) LimitIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.limitsMu.RLock()
+		limit := l.ip
+		l.limitsMu.RUnlock()
+
+		if limit == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		l.serve(w, r, "*", *limit, l.addr(r), next.ServeHTTP)
+	})
+}
+
+func (l *Limiter) serve(w http.ResponseWriter, r *http.Request, route string, limit Limit, client string, next http.HandlerFunc) {
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds()))
+	st := l.take(route+" "+client, limit)
+
+	h := w.Header()
+	h.Set("RateLimit-Policy", policy)
+	h.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	h.Set("RateLimit-Remaining", strconv.Itoa(st.remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(st.reset))
+
+	if !st.allowed {
+		l.logger.Warn("rate limit exceeded", zap.String("route", route), zap.String("client", client))
+
+		h.Set("Retry-After", ceilSeconds(st.retryAfter))
+		apperr.Write(w, r, l.logger, apperr.New(http.StatusTooManyRequests, RateLimited, "rate limit exceeded"))
+
+		return
+	}
+
+	next(w, r)
+}
+
+func (l *Limiter) take(key string, limit Limit) state {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.buckets[key]
+	if !ok {
+		e = entry{bucket: newBucket(limit, now)}
+	}
+
+	// the limit may have been reloaded since the bucket was created.
+	e.limit = limit
+	l.buckets[key] = e
+
+	return e.bucket.take(limit, now)
+}
+
+// Cleanup drops refilled buckets every interval until the context is done.
+func (l *Limiter) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.cleanup()
+		}
+	}
+}
+
+func (l *Limiter) cleanup() {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, e := range l.buckets {
+		if e.bucket.full(e.limit, now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *Limiter) client(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.Method + ":" + p.Subject
+	}
+
+	return l.addr(r)
+}
+
+// addr identifies the client by its address. Behind the trusted proxy it's the last X-Forwarded-For entry,
+// the one appended by the proxy, the entries before it come from the client and may be forged.
+func (l *Limiter) addr(r *http.Request) string {
+	if fwd := r.Header.Values("X-Forwarded-For"); l.trustProxy && len(fwd) > 0 {
+		last := fwd[len(fwd)-1]
+
+		if i := strings.LastIndex(last, ","); i >= 0 {
+			last = last[i+1:]
+		}
+
+		if ip := strings.TrimSpace(last); ip != "" {
+			return "ip:" + ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
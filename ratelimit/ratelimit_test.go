@@ -0,0 +1,271 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/config"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(zap.NewNop(), config.RateLimitCfg{
+		Default: "10/1s",
+		Routes:  map[string]string{"GET /v1/users": "fast"},
+	})
+	assert.EqualError(t, err, `route GET /v1/users: limit "fast" must be <requests>/<period>`)
+}
+
+func TestLimiter_Limit(t *testing.T) {
+	l, err := New(zap.NewNop(), config.RateLimitCfg{
+		Default: "10/1s",
+		Routes:  map[string]string{"GET /v1/users": "1/1m"},
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	next := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}
+
+	handler := l.Limit("GET /v1/users", next)
+
+	type want struct {
+		code       int
+		remaining  string
+		reset      string
+		retryAfter string
+		body       string
+	}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		remote    string
+		want      want
+	}{
+		{
+			name:      "first request",
+			principal: &auth.Principal{Subject: "alice", Method: auth.MethodJWT},
+			want:      want{code: http.StatusOK, remaining: "0", reset: "60", body: "ok"},
+		},
+		{
+			name:      "limited",
+			principal: &auth.Principal{Subject: "alice", Method: auth.MethodJWT},
+			want: want{
+				code:       http.StatusTooManyRequests,
+				remaining:  "0",
+				reset:      "60",
+				retryAfter: "60",
+				body:       `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"rate limit exceeded","instance":"/v1/users","code":"RATE_LIMITED"}`,
+			},
+		},
+		{
+			name:      "api key has its own bucket",
+			principal: &auth.Principal{Subject: "alice", Method: auth.MethodAPIKey},
+			want:      want{code: http.StatusOK, remaining: "0", reset: "60", body: "ok"},
+		},
+		{
+			name:   "anonymous by ip",
+			remote: "10.0.0.1:1234",
+			want:   want{code: http.StatusOK, remaining: "0", reset: "60", body: "ok"},
+		},
+		{
+			name:   "same ip another port",
+			remote: "10.0.0.1:4321",
+			want: want{
+				code:       http.StatusTooManyRequests,
+				remaining:  "0",
+				reset:      "60",
+				retryAfter: "60",
+				body:       `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"rate limit exceeded","instance":"/v1/users","code":"RATE_LIMITED"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			if tt.remote != "" {
+				req.RemoteAddr = tt.remote
+			}
+
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			assert.Equal(t, "1;w=60", res.Header.Get("RateLimit-Policy"))
+			assert.Equal(t, "1", res.Header.Get("RateLimit-Limit"))
+			assert.Equal(t, tt.want.remaining, res.Header.Get("RateLimit-Remaining"))
+			assert.Equal(t, tt.want.reset, res.Header.Get("RateLimit-Reset"))
+			assert.Equal(t, tt.want.retryAfter, res.Header.Get("Retry-After"))
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.body, string(data))
+		})
+	}
+
+	// another route falls back to the default limit.
+	w := httptest.NewRecorder()
+	l.Limit("GET /v1/groups", next)(w, httptest.NewRequest(http.MethodGet, "/v1/groups", nil))
+	assert.Equal(t, "10", w.Result().Header.Get("RateLimit-Limit"))
+
+	now = now.Add(time.Minute)
+	l.cleanup()
+	assert.Empty(t, l.buckets)
+}
+
+type failingAuth struct {
+	calls int
+}
+
+func (a *failingAuth) Scheme() string {
+	return "Bearer"
+}
+
+func (a *failingAuth) Authenticate(context.Context, string) (*auth.Principal, error) {
+	a.calls++
+	return nil, errors.New("invalid token")
+}
+
+func TestLimiter_LimitIP(t *testing.T) {
+	l, err := New(zap.NewNop(), config.RateLimitCfg{Default: "10/1s", IP: "2/1m"})
+	require.NoError(t, err)
+
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	authenticator := new(failingAuth)
+	next := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}
+
+	handler := l.LimitIP(auth.Middleware(zap.NewNop(), "test", authenticator)(l.Limit("GET /v1/users", next)))
+
+	do := func(remote string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("Authorization", "Bearer bogus")
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w.Result()
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("10.0.0.1:1234").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, do("10.0.0.1:1234").StatusCode)
+
+	// invalid credentials don't reach the authenticator past the limit.
+	res := do("10.0.0.1:4321")
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "30", res.Header.Get("Retry-After"))
+	assert.Equal(t, 2, authenticator.calls)
+
+	assert.Equal(t, http.StatusUnauthorized, do("10.0.0.2:1234").StatusCode)
+	assert.Equal(t, 3, authenticator.calls)
+
+	// an empty limit disables it.
+	require.NoError(t, l.Reload(config.RateLimitCfg{Default: "10/1s"}))
+	assert.Equal(t, http.StatusUnauthorized, do("10.0.0.1:1234").StatusCode)
+
+	err = l.Reload(config.RateLimitCfg{Default: "10/1s", IP: "fast"})
+	assert.EqualError(t, err, `ip: limit "fast" must be <requests>/<period>`)
+}
+
+func TestLimiter_client(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		forwarded  string
+		want       string
+	}{
+		{
+			name:      "remote address",
+			forwarded: "203.0.113.7",
+			want:      "ip:192.0.2.1",
+		},
+		{
+			name:       "trusted proxy",
+			trustProxy: true,
+			forwarded:  "203.0.113.7",
+			want:       "ip:203.0.113.7",
+		},
+		{
+			name:       "forged entries",
+			trustProxy: true,
+			forwarded:  "10.0.0.1, 198.51.100.3,203.0.113.7",
+			want:       "ip:203.0.113.7",
+		},
+		{
+			name:       "empty entry",
+			trustProxy: true,
+			forwarded:  "203.0.113.7, ",
+			want:       "ip:192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Limiter{trustProxy: tt.trustProxy}
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+
+			assert.Equal(t, tt.want, l.client(req))
+		})
+	}
+}
+
+func TestLimiter_Reload(t *testing.T) {
+	l, err := New(zap.NewNop(), config.RateLimitCfg{Default: "1/1m"})
+	require.NoError(t, err)
+
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	handler := l.Limit("GET /v1/users", func(w http.ResponseWriter, _ *http.Request) {})
+
+	do := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, do().Code)
+	assert.Equal(t, http.StatusTooManyRequests, do().Code)
+
+	err = l.Reload(config.RateLimitCfg{Default: "1/1m", Routes: map[string]string{"GET /v1/users": "fast"}})
+	assert.EqualError(t, err, `route GET /v1/users: limit "fast" must be <requests>/<period>`)
+	assert.Equal(t, http.StatusTooManyRequests, do().Code)
+
+	require.NoError(t, l.Reload(config.RateLimitCfg{Default: "1/1m", Routes: map[string]string{"GET /v1/users": "3600/1s"}}))
+
+	// the bucket is refilled at the new rate.
+	now = now.Add(time.Second)
+
+	rec := do()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "3600", rec.Header().Get("RateLimit-Limit"))
+}
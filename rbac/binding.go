@@ -0,0 +1,28 @@
+package rbac
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// Binding grants the role to the subject of a principal: JWT subject or API key id.
+type Binding struct {
+	ID        uuid.UUID `json:"id"`
+	Subject   string    `json:"subject"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+// DTO represent data transfer object for creating a new binding.
+type DTO struct {
+	Subject string `validate:"required" json:"subject,omitempty"`
+	Role    string `validate:"required" json:"role,omitempty"`
+}
+
+// Validate check mandatory fields.
+func (d DTO) Validate() error {
+	return apperr.ValidateStruct(d)
+}
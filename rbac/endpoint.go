@@ -0,0 +1,170 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+type service interface {
+	ListBindings(ctx context.Context, subject string) ([]*Binding, error)
+	CreateBinding(ctx context.Context, dto DTO) (*Binding, error)
+	DeleteBinding(ctx context.Context, id uuid.UUID) error
+}
+
+type Endpoint struct {
+	logger *zap.Logger
+	svc    service
+}
+
+func NewEndpoint(logger *zap.Logger, svc service) *Endpoint {
+	return &Endpoint{logger: logger, svc: svc}
+}
+
+type response struct {
+	Data []*Binding `json:"data,omitempty"`
+}
+
+type rolesResponse struct {
+	Data []Role `json:"data"`
+}
+
+// ListRoles http list built-in roles handler.
+// @Title ListRoles
+// @Tags RBAC
+// @Accept json
+// @Produce json,application/problem+json
+// @Description list built-in roles and their permissions
+// @Summary fetch roles
+// @Success 200 {object} rolesResponse
+// @Router /v1/roles [GET]
+func (e *Endpoint) ListRoles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	e.writeResp(w, r, rolesResponse{Data: Roles()})
+}
+
+// ListBindings http list role bindings handler.
+// @Title ListBindings
+// @Tags RBAC
+// @Accept json
+// @Produce json,application/problem+json
+// @Description list role bindings, optionally of a single subject
+// @Summary fetch role bindings
+// @Success 200 {object} response
+// @Failure 500 {object} apperr.Problem
+// @Param subject query string false "Subject"
+// @Router /v1/role-bindings [GET]
+func (e *Endpoint) ListBindings(w http.ResponseWriter, r *http.Request) {
+	var resp response
+
+	w.Header().Set("Content-Type", "application/json")
+
+	models, err := e.svc.ListBindings(r.Context(), r.URL.Query().Get("subject"))
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = models
+	e.writeResp(w, r, resp)
+}
+
+// CreateBinding http create role binding handler.
+// @Title CreateBinding
+// @Tags RBAC
+// @Accept json
+// @Produce json,application/problem+json
+// @Description grant the role to the subject
+// @Summary create role binding
+// @Success 201 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 409 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 500 {object} apperr.Problem
+// @Param model body DTO true "New model"
+// @Router /v1/role-bindings [POST]
+func (e *Endpoint) CreateBinding(w http.ResponseWriter, r *http.Request) {
+	var (
+		dto  DTO
+		resp response
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		e.logger.Warn("decode role binding data", zap.Error(err))
+		e.writeErr(w, r, newDecodeErr(InvalidBindingData, &dto, err))
+
+		return
+	}
+
+	model, err := e.svc.CreateBinding(r.Context(), dto)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = append(resp.Data, model)
+
+	w.WriteHeader(http.StatusCreated)
+	e.writeResp(w, r, resp)
+}
+
+// DeleteBinding http delete role binding handler.
+// @Title DeleteBinding
+// @Tags RBAC
+// @Accept json
+// @Produce json,application/problem+json
+// @Description revoke the role from the subject
+// @Summary delete role binding
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param id path string true "Role binding ID"
+// @Router /v1/role-bindings/{id} [DELETE]
+func (e *Endpoint) DeleteBinding(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		e.logger.Warn("could not parse role binding id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidBindingID, err.Error()))
+
+		return
+	}
+
+	if err := e.svc.DeleteBinding(r.Context(), id); err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	var resp response
+
+	resp.Data = []*Binding{}
+
+	e.writeResp(w, r, resp)
+}
+
+func (e *Endpoint) writeResp(w http.ResponseWriter, r *http.Request, uData any) {
+	data, err := json.Marshal(uData)
+	if err != nil {
+		e.writeErr(w, r, newInternalServer(InternalServerError, err.Error()))
+		e.logger.Warn("marshal data", zap.Error(err))
+		return
+	}
+
+	if _, err = w.Write(data); err != nil {
+		e.logger.Error("write error", zap.Error(err))
+	}
+}
+
+func (e *Endpoint) writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	apperr.Write(w, r, e.logger, err)
+}
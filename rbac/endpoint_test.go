@@ -0,0 +1,125 @@
+package rbac
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestEndpoint_CreateBinding(t *testing.T) {
+	svc := new(MockServer)
+
+	setCreate := func(dto DTO, binding *Binding, err error) {
+		svc.On("CreateBinding", mock.Anything, dto).Return(binding, err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		body         []byte
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "success",
+			body: []byte(`{"subject":"alice","role":"editor"}`),
+			setup: func() {
+				setCreate(
+					DTO{Subject: "alice", Role: RoleEditor},
+					&Binding{
+						ID:        bindingID,
+						Subject:   "alice",
+						Role:      RoleEditor,
+						CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+					},
+					nil,
+				)
+			},
+			wantHTTPCode: http.StatusCreated,
+			want:         []byte(`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","subject":"alice","role":"editor","createdAt":"2022-11-17T20:00:00Z"}]}`),
+		},
+		{
+			name: "conflict",
+			body: []byte(`{"subject":"alice","role":"editor"}`),
+			setup: func() {
+				setCreate(
+					DTO{Subject: "alice", Role: RoleEditor},
+					nil,
+					newConflictErr(BindingExists, "subject already has the role"),
+				)
+			},
+			wantHTTPCode: http.StatusConflict,
+			want:         []byte(`{"type":"about:blank","title":"Conflict","status":409,"detail":"subject already has the role","instance":"/v1/role-bindings","code":"ROLE_BINDING_EXISTS"}`),
+		},
+		{
+			name:         "invalid body",
+			body:         []byte(`[`),
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"body must be valid JSON","instance":"/v1/role-bindings","code":"INVALID_ROLE_BINDING_DATA","errors":[{"rule":"json","message":"body must be valid JSON"}]}`),
+		},
+	}
+
+	e := NewEndpoint(zap.NewNop(), svc)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/role-bindings", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			e.CreateBinding(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, string(tt.want), string(data))
+		})
+	}
+}
+
+func TestEndpoint_ListBindings(t *testing.T) {
+	svc := new(MockServer)
+
+	svc.On("ListBindings", mock.Anything, "alice").Return([]*Binding{
+		{
+			ID:        bindingID,
+			Subject:   "alice",
+			Role:      RoleViewer,
+			CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+		},
+	}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/role-bindings?subject=alice", nil)
+	w := httptest.NewRecorder()
+
+	NewEndpoint(zap.NewNop(), svc).ListBindings(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	data, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","subject":"alice","role":"viewer","createdAt":"2022-11-17T20:00:00Z"}]}`,
+		string(data),
+	)
+	svc.AssertExpectations(t)
+}
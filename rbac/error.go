@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// service error codes.
+const (
+	InvalidBindingID    = "INVALID_ROLE_BINDING_ID"
+	InvalidBindingData  = "INVALID_ROLE_BINDING_DATA"
+	BindingExists       = "ROLE_BINDING_EXISTS"
+	UnknownRole         = "UNKNOWN_ROLE"
+	Forbidden           = "FORBIDDEN"
+	InternalServerError = apperr.InternalServerError
+	NotFound            = apperr.NotFound
+	ValidationError     = apperr.ValidationError
+)
+
+var (
+	errNotExists     = errors.New("not exists")
+	errAlreadyExists = errors.New("already exists")
+)
+
+// ServiceError represent service custom error.
+type ServiceError = apperr.ServiceError
+
+func newBadRequest(code, msg string) *ServiceError {
+	return apperr.NewBadRequest(code, msg)
+}
+
+func newInternalServer(code, msg string) *ServiceError {
+	return apperr.NewInternalServer(code, msg)
+}
+
+func newNotFoundErr(code, msg string) *ServiceError {
+	return apperr.NewNotFound(code, msg)
+}
+
+func newValidationErr(code, msg string) *ServiceError {
+	return apperr.NewValidation(code, msg)
+}
+
+func newConflictErr(code, msg string) *ServiceError {
+	return apperr.New(http.StatusConflict, code, msg)
+}
+
+func newForbiddenErr(code, msg string) *ServiceError {
+	return apperr.New(http.StatusForbidden, code, msg)
+}
+
+func newDecodeErr(code string, dst any, err error) *ServiceError {
+	return apperr.NewDecode(code, dst, err)
+}
+
+func newFieldValidationErr(code string, err error) *ServiceError {
+	return apperr.NewFieldValidation(code, err)
+}
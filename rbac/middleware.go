@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+	"github.com/ihippik/template-service/auth"
+)
+
+type roleSource interface {
+	RolesOf(ctx context.Context, subject string) ([]string, error)
+}
+
+type ctxKey struct{}
+
+// WithRoles returns a copy of the context carrying the roles of the caller.
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, roles)
+}
+
+// RolesFromContext returns the roles of the caller stored in the context.
+func RolesFromContext(ctx context.Context) ([]string, bool) {
+	roles, ok := ctx.Value(ctxKey{}).([]string)
+	return roles, ok
+}
+
+// Authorizer checks permissions declared by routes against the roles bound to the principal.
+type Authorizer struct {
+	logger *zap.Logger
+	roles  roleSource
+}
+
+// NewAuthorizer creates new Authorizer instance.
+func NewAuthorizer(logger *zap.Logger, roles roleSource) *Authorizer {
+	return &Authorizer{logger: logger, roles: roles}
+}
+
+// Require wraps the handler, the request is rejected with 403 unless the principal has the permission.
+// Roles of the principal are put into the request context.
+func (a *Authorizer) Require(perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			a.deny(w, r, perm, "")
+			return
+		}
+
+		roles, ok := RolesFromContext(r.Context())
+		if !ok {
+			var err error
+
+			roles, err = a.roles.RolesOf(r.Context(), principal.Subject)
+			if err != nil {
+				apperr.Write(w, r, a.logger, err)
+				return
+			}
+		}
+
+		if !Allowed(perm, roles, principal.Scopes) {
+			a.deny(w, r, perm, principal.Subject)
+			return
+		}
+
+		next(w, r.WithContext(WithRoles(r.Context(), roles)))
+	}
+}
+
+func (a *Authorizer) deny(w http.ResponseWriter, r *http.Request, perm Permission, subject string) {
+	a.logger.Warn("permission denied", zap.String("subject", subject), zap.String("permission", string(perm)))
+
+	apperr.Write(w, r, a.logger, newForbiddenErr(Forbidden, "permission "+string(perm)+" is required"))
+}
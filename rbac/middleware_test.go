@@ -0,0 +1,114 @@
+package rbac
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/auth"
+)
+
+func TestAuthorizer_Require(t *testing.T) {
+	roles := new(MockServer)
+
+	setRoles := func(subject string, names []string, err error) {
+		roles.On("RolesOf", mock.Anything, subject).Return(names, err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		principal    *auth.Principal
+		perm         Permission
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name:      "allowed by role",
+			principal: &auth.Principal{Subject: "alice"},
+			perm:      UsersWrite,
+			setup: func() {
+				setRoles("alice", []string{RoleEditor}, nil)
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte("editor"),
+		},
+		{
+			name:      "allowed by scope",
+			principal: &auth.Principal{Subject: "alice", Scopes: []string{"users:delete"}},
+			perm:      UsersDelete,
+			setup: func() {
+				setRoles("alice", []string{}, nil)
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte(""),
+		},
+		{
+			name:      "denied",
+			principal: &auth.Principal{Subject: "alice"},
+			perm:      UsersDelete,
+			setup: func() {
+				setRoles("alice", []string{RoleEditor}, nil)
+			},
+			wantHTTPCode: http.StatusForbidden,
+			want:         []byte(`{"type":"about:blank","title":"Forbidden","status":403,"detail":"permission users:delete is required","instance":"/v1/users","code":"FORBIDDEN"}`),
+		},
+		{
+			name:         "anonymous",
+			perm:         UsersRead,
+			setup:        func() {},
+			wantHTTPCode: http.StatusForbidden,
+			want:         []byte(`{"type":"about:blank","title":"Forbidden","status":403,"detail":"permission users:read is required","instance":"/v1/users","code":"FORBIDDEN"}`),
+		},
+		{
+			name:      "roles error",
+			principal: &auth.Principal{Subject: "alice"},
+			perm:      UsersRead,
+			setup: func() {
+				setRoles("alice", nil, errors.New("some error"))
+			},
+			wantHTTPCode: http.StatusInternalServerError,
+			want:         []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users","code":"INTERNAL_SERVER_ERROR"}`),
+		},
+	}
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		names, _ := RolesFromContext(r.Context())
+		_, _ = w.Write([]byte(strings.Join(names, ",")))
+	}
+
+	a := NewAuthorizer(zap.NewNop(), roles)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer roles.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+
+			w := httptest.NewRecorder()
+
+			a.Require(tt.perm, next)(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, string(tt.want), string(data))
+		})
+	}
+}
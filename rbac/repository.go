@@ -0,0 +1,106 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/ihippik/template-service/metrics"
+	"github.com/ihippik/template-service/tenant"
+)
+
+// uniqueViolation is a PostgreSQL error code.
+const uniqueViolation = "23505"
+
+// Repository is a database PostgreSQL repository.
+// Every query runs in a transaction bound to the tenant from the context.
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates new Repository instance.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// List receive bindings from the database, all of them when the subject is empty.
+func (r *Repository) List(ctx context.Context, subject string) (_ []*Binding, err error) {
+	defer metrics.ObserveQuery("rbac", "List", time.Now(), &err)
+
+	var models []*Binding
+
+	err = tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(
+			ctx,
+			&models,
+			`SELECT id, subject, role, created_at FROM role_bindings
+			WHERE $1 = '' OR subject = $1 ORDER BY subject, role`,
+			subject,
+		)
+		if err != nil {
+			return fmt.Errorf("select: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+// Create new binding in the database, errAlreadyExists is returned when the subject has the role.
+func (r *Repository) Create(ctx context.Context, binding *Binding) (err error) {
+	defer metrics.ObserveQuery("rbac", "Create", time.Now(), &err)
+
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO role_bindings (id, subject, role, created_at) VALUES($1, $2, $3, $4)",
+			binding.ID,
+			binding.Subject,
+			binding.Role,
+			binding.CreatedAt,
+		)
+
+		var pqErr *pq.Error
+
+		switch {
+		case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+			return errAlreadyExists
+		case err != nil:
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Delete binding from the database by its id.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	defer metrics.ObserveQuery("rbac", "Delete", time.Now(), &err)
+
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM role_bindings WHERE id=$1", id)
+		if err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		if affected == 0 {
+			return errNotExists
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,27 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) List(ctx context.Context, subject string) ([]*Binding, error) {
+	args := m.Called(ctx, subject)
+	return args.Get(0).([]*Binding), args.Error(1)
+}
+
+func (m *MockRepo) Create(ctx context.Context, binding *Binding) error {
+	args := m.Called(ctx, binding)
+	return args.Error(0)
+}
+
+func (m *MockRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
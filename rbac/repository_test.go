@@ -0,0 +1,130 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/tenant"
+)
+
+func TestRepository_List(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery(prepareSQL(`SELECT id, subject, role, created_at FROM role_bindings
+			WHERE $1 = '' OR subject = $1 ORDER BY subject, role`)).
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subject", "role", "created_at"}).AddRow(
+			bindingID.String(),
+			"alice",
+			"viewer",
+			time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+		))
+	expectTxEnd(mock, nil)
+
+	got, err := r.List(tenantCtx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []*Binding{
+		{
+			ID:        bindingID,
+			Subject:   "alice",
+			Role:      "viewer",
+			CreatedAt: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{db: sqlx.NewDb(mockDB, "sqlmock")}
+
+	binding := Binding{
+		ID:        bindingID,
+		Subject:   "alice",
+		Role:      RoleViewer,
+		CreatedAt: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "success",
+			wantErr: nil,
+		},
+		{
+			name:    "already exists",
+			err:     &pq.Error{Code: uniqueViolation},
+			wantErr: errors.New("already exists"),
+		},
+		{
+			name:    "some error",
+			err:     errors.New("some error"),
+			wantErr: errors.New("exec: some error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+			mock.ExpectExec(prepareSQL(`INSERT INTO role_bindings (id, subject, role, created_at) VALUES($1, $2, $3, $4)`)).
+				WithArgs(binding.ID, binding.Subject, binding.Role, binding.CreatedAt).
+				WillReturnResult(sqlmock.NewResult(0, 1)).
+				WillReturnError(tt.err)
+			expectTxEnd(mock, tt.wantErr)
+
+			err := r.Create(tenantCtx, &binding)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func prepareSQL(sql string) string {
+	replacer := strings.NewReplacer("$", "\\$", "(", "\\(", ")", "\\)")
+	return replacer.Replace(sql)
+}
+
+var tenantCtx = tenant.WithID(context.Background(), uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d"))
+
+func expectTenantTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(prepareSQL(`SELECT set_config('app.tenant_id', $1, true)`)).
+		WithArgs("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectTxEnd(mock sqlmock.Sqlmock, err error) {
+	if err != nil {
+		mock.ExpectRollback()
+		return
+	}
+
+	mock.ExpectCommit()
+}
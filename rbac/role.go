@@ -0,0 +1,83 @@
+package rbac
+
+import "sort"
+
+// Permission is an action allowed on a resource.
+type Permission string
+
+// permissions declared by routes.
+const (
+	UsersRead          Permission = "users:read"
+	UsersWrite         Permission = "users:write"
+	UsersDelete        Permission = "users:delete"
+	GroupsRead         Permission = "groups:read"
+	GroupsWrite        Permission = "groups:write"
+	GroupsDelete       Permission = "groups:delete"
+	APIKeysManage      Permission = "api_keys:manage"
+	RoleBindingsManage Permission = "role_bindings:manage"
+)
+
+// built-in roles.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+var roles = map[string][]Permission{
+	RoleViewer: {UsersRead, GroupsRead},
+	RoleEditor: {UsersRead, UsersWrite, GroupsRead, GroupsWrite},
+	RoleAdmin: {
+		UsersRead, UsersWrite, UsersDelete,
+		GroupsRead, GroupsWrite, GroupsDelete,
+		APIKeysManage, RoleBindingsManage,
+	},
+}
+
+// Roles returns built-in roles sorted by name.
+func Roles() []Role {
+	res := make([]Role, 0, len(roles))
+
+	for name, perms := range roles {
+		res = append(res, Role{Name: name, Permissions: perms})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// IsRole reports whether the role is a built-in one.
+func IsRole(name string) bool {
+	_, ok := roles[name]
+	return ok
+}
+
+// Allowed reports whether one of the roles or scopes grants the permission.
+// Scopes are the permissions granted by the token issuer or the API key,
+// they let the first admin manage role bindings before any binding exists.
+func Allowed(perm Permission, roleNames, scopes []string) bool {
+	for _, scope := range scopes {
+		if Permission(scope) == perm {
+			return true
+		}
+	}
+
+	for _, name := range roleNames {
+		for _, p := range roles[name] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		perm   Permission
+		roles  []string
+		scopes []string
+		want   bool
+	}{
+		{
+			name:  "viewer reads",
+			perm:  UsersRead,
+			roles: []string{RoleViewer},
+			want:  true,
+		},
+		{
+			name:  "viewer can not write",
+			perm:  UsersWrite,
+			roles: []string{RoleViewer},
+			want:  false,
+		},
+		{
+			name:  "any of the roles",
+			perm:  UsersWrite,
+			roles: []string{RoleViewer, RoleEditor},
+			want:  true,
+		},
+		{
+			name:  "editor can not delete",
+			perm:  UsersDelete,
+			roles: []string{RoleEditor},
+			want:  false,
+		},
+		{
+			name:  "admin deletes",
+			perm:  UsersDelete,
+			roles: []string{RoleAdmin},
+			want:  true,
+		},
+		{
+			name:  "unknown role",
+			perm:  UsersRead,
+			roles: []string{"root"},
+			want:  false,
+		},
+		{
+			name:   "scope",
+			perm:   RoleBindingsManage,
+			scopes: []string{"openid", "role_bindings:manage"},
+			want:   true,
+		},
+		{
+			name: "nothing",
+			perm: UsersRead,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Allowed(tt.perm, tt.roles, tt.scopes))
+		})
+	}
+}
+
+func TestRoles(t *testing.T) {
+	got := Roles()
+
+	names := make([]string, 0, len(got))
+	for _, role := range got {
+		names = append(names, role.Name)
+	}
+
+	assert.Equal(t, []string{RoleAdmin, RoleEditor, RoleViewer}, names)
+}
@@ -0,0 +1,130 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/logging"
+	"github.com/ihippik/template-service/tracing"
+)
+
+type repository interface {
+	List(ctx context.Context, subject string) ([]*Binding, error)
+	Create(ctx context.Context, binding *Binding) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Service represent the role binding management logic.
+type Service struct {
+	cfg    *config.Config
+	logger *zap.Logger
+	repo   repository
+}
+
+var timeNow = time.Now
+
+// NewService creates new Service entity.
+func NewService(cfg *config.Config, logger *zap.Logger, repo repository) *Service {
+	return &Service{cfg: cfg, logger: logger, repo: repo}
+}
+
+// ListBindings fetch role bindings, all of them when the subject is empty.
+func (svc *Service) ListBindings(ctx context.Context, subject string) (_ []*Binding, err error) {
+	ctx, span := tracing.Start(ctx, "rbac.Service.ListBindings")
+	defer tracing.End(span, &err)
+
+	models, err := svc.repo.List(ctx, subject)
+	if err != nil {
+		svc.log(ctx).Error("could not fetch role bindings", zap.Error(err))
+		return nil, fmt.Errorf("list: %w", err)
+	}
+
+	return models, nil
+}
+
+// RolesOf returns names of the roles bound to the subject.
+func (svc *Service) RolesOf(ctx context.Context, subject string) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "rbac.Service.RolesOf")
+	defer tracing.End(span, &err)
+
+	models, err := svc.ListBindings(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(models))
+
+	for _, model := range models {
+		names = append(names, model.Role)
+	}
+
+	return names, nil
+}
+
+// CreateBinding grants the built-in role to the subject.
+func (svc *Service) CreateBinding(ctx context.Context, dto DTO) (_ *Binding, err error) {
+	ctx, span := tracing.Start(ctx, "rbac.Service.CreateBinding")
+	defer tracing.End(span, &err)
+
+	if err := dto.Validate(); err != nil {
+		svc.log(ctx).Warn("dto validation error", zap.Error(err))
+		return nil, newFieldValidationErr(ValidationError, err)
+	}
+
+	if !IsRole(dto.Role) {
+		svc.log(ctx).Warn("unknown role", zap.String("role", dto.Role))
+		return nil, newValidationErr(UnknownRole, fmt.Sprintf("unknown role %q", dto.Role))
+	}
+
+	model := Binding{
+		ID:        uuid.New(),
+		Subject:   dto.Subject,
+		Role:      dto.Role,
+		CreatedAt: timeNow().UTC(),
+	}
+
+	err = svc.repo.Create(ctx, &model)
+	if err == nil {
+		return &model, nil
+	}
+
+	if errors.Is(err, errAlreadyExists) {
+		svc.log(ctx).Warn("role binding already exists", zap.String("subject", dto.Subject), zap.String("role", dto.Role))
+		return nil, newConflictErr(BindingExists, "subject already has the role")
+	}
+
+	svc.log(ctx).Error("could not create role binding", zap.Error(err))
+
+	return nil, fmt.Errorf("could not create role binding: %w", err)
+}
+
+// DeleteBinding delete a binding by its identification.
+func (svc *Service) DeleteBinding(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "rbac.Service.DeleteBinding")
+	defer tracing.End(span, &err)
+
+	err = svc.repo.Delete(ctx, id)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, errNotExists) {
+		svc.log(ctx).Warn("role binding not found", zap.String("id", id.String()))
+		return newNotFoundErr(NotFound, "role binding not found")
+	}
+
+	svc.log(ctx).Error("could not delete role binding", zap.Error(err))
+
+	return fmt.Errorf("delete role binding: %w", err)
+}
+
+// log returns the request-scoped logger of the context.
+func (svc *Service) log(ctx context.Context) *zap.Logger {
+	return logging.FromContext(ctx, svc.logger)
+}
@@ -0,0 +1,32 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockServer struct {
+	mock.Mock
+}
+
+func (m *MockServer) ListBindings(ctx context.Context, subject string) ([]*Binding, error) {
+	args := m.Called(ctx, subject)
+	return args.Get(0).([]*Binding), args.Error(1)
+}
+
+func (m *MockServer) CreateBinding(ctx context.Context, dto DTO) (*Binding, error) {
+	args := m.Called(ctx, dto)
+	return args.Get(0).(*Binding), args.Error(1)
+}
+
+func (m *MockServer) DeleteBinding(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockServer) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	args := m.Called(ctx, subject)
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -0,0 +1,162 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+var bindingID = uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+
+func TestService_CreateBinding(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	repo := new(MockRepo)
+
+	setCreate := func(subject, role string, err error) {
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(b *Binding) bool {
+			return b.Subject == subject && b.Role == role && b.CreatedAt.Equal(timeNow())
+		})).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		dto     DTO
+		setup   func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			dto:  DTO{Subject: "alice", Role: RoleEditor},
+			setup: func() {
+				setCreate("alice", RoleEditor, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "validation error",
+			dto:     DTO{Role: RoleEditor},
+			setup:   func() {},
+			wantErr: newValidationErr(ValidationError, "subject is required"),
+		},
+		{
+			name:    "unknown role",
+			dto:     DTO{Subject: "alice", Role: "root"},
+			setup:   func() {},
+			wantErr: newValidationErr(UnknownRole, `unknown role "root"`),
+		},
+		{
+			name: "already exists",
+			dto:  DTO{Subject: "alice", Role: RoleEditor},
+			setup: func() {
+				setCreate("alice", RoleEditor, errAlreadyExists)
+			},
+			wantErr: newConflictErr(BindingExists, "subject already has the role"),
+		},
+		{
+			name: "some error",
+			dto:  DTO{Subject: "alice", Role: RoleEditor},
+			setup: func() {
+				setCreate("alice", RoleEditor, errors.New("some error"))
+			},
+			wantErr: errors.New("could not create role binding: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.CreateBinding(context.Background(), tt.dto)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+
+			assert.NoError(t, tt.wantErr)
+			assert.Equal(t, tt.dto.Subject, got.Subject)
+			assert.Equal(t, tt.dto.Role, got.Role)
+		})
+	}
+}
+
+func TestService_DeleteBinding(t *testing.T) {
+	repo := new(MockRepo)
+
+	setDelete := func(err error) {
+		repo.On("Delete", mock.Anything, bindingID).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr error
+	}{
+		{
+			name: "success",
+			setup: func() {
+				setDelete(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			setup: func() {
+				setDelete(errNotExists)
+			},
+			wantErr: newNotFoundErr(NotFound, "role binding not found"),
+		},
+		{
+			name: "some error",
+			setup: func() {
+				setDelete(errors.New("some error"))
+			},
+			wantErr: errors.New("delete role binding: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			err := svc.DeleteBinding(context.Background(), bindingID)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_RolesOf(t *testing.T) {
+	repo := new(MockRepo)
+
+	repo.On("List", mock.Anything, "alice").Return([]*Binding{
+		{ID: bindingID, Subject: "alice", Role: RoleViewer},
+		{ID: uuid.New(), Subject: "alice", Role: RoleEditor},
+	}, nil).Once()
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	got, err := svc.RolesOf(context.Background(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{RoleViewer, RoleEditor}, got)
+	repo.AssertExpectations(t)
+}
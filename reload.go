@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/ratelimit"
+)
+
+// reloadable settings are applied on SIGHUP, others need a restart.
+var reloadable = map[string]struct{}{
+	"log.level":          {},
+	"db.max_open_conns":  {},
+	"db.max_idle_conns":  {},
+	"rate_limit.default": {},
+	"rate_limit.routes":  {},
+	"rate_limit.ip":      {},
+}
+
+// reloader re-reads the configuration on SIGHUP and applies the reloadable settings
+// to the running service, open connections are kept.
+type reloader struct {
+	logger  *zap.Logger
+	src     config.Sources
+	cfg     *config.Config
+	level   zap.AtomicLevel
+	db      *sqlx.DB
+	limiter *ratelimit.Limiter
+}
+
+// Watch reloads the configuration on every SIGHUP until the context is done.
+// An invalid configuration is logged and the current one stays in effect.
+func (r *reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := r.reload(ctx); err != nil {
+				r.logger.Error("could not reload config, keeping the current one", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (r *reloader) reload(ctx context.Context) error {
+	cfg, err := config.New(ctx, r.src)
+	if err != nil {
+		return err
+	}
+
+	changes := config.Diff(r.cfg, cfg)
+	if len(changes) == 0 {
+		r.logger.Info("config was reloaded without changes")
+		return nil
+	}
+
+	var level zapcore.Level
+
+	if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+
+	// nothing is applied when the limits are invalid.
+	if err := r.limiter.Reload(cfg.RateLimit); err != nil {
+		return fmt.Errorf("rate limit: %w", err)
+	}
+
+	// the level set through the admin endpoint stays until the config changes it.
+	if cfg.Log.Level != r.cfg.Log.Level {
+		r.level.SetLevel(level)
+	}
+
+	r.db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	r.db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+
+	r.cfg.Log.Level = cfg.Log.Level
+	r.cfg.DB.MaxOpenConns = cfg.DB.MaxOpenConns
+	r.cfg.DB.MaxIdleConns = cfg.DB.MaxIdleConns
+	r.cfg.RateLimit.Default = cfg.RateLimit.Default
+	r.cfg.RateLimit.Routes = cfg.RateLimit.Routes
+	r.cfg.RateLimit.IP = cfg.RateLimit.IP
+
+	for _, c := range changes {
+		if _, ok := reloadable[c.Key]; ok {
+			r.logger.Info("config setting was reloaded", zap.String("key", c.Key), zap.Any("old", c.Old), zap.Any("new", c.New))
+			continue
+		}
+
+		r.logger.Warn(
+			"config setting changed, restart to apply",
+			zap.String("key", c.Key), zap.Any("old", c.Old), zap.Any("new", c.New),
+		)
+	}
+
+	return nil
+}
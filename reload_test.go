@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/ratelimit"
+)
+
+func TestReloader_reload(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer mockDB.Close()
+
+	flags := map[string]string{"SERVER_ADDR": ":8000", "DB_CONN": "postgres://localhost/db"}
+	src := config.Sources{Flags: flags}
+
+	cfg, err := config.New(context.Background(), src)
+	require.NoError(t, err)
+
+	limiter, err := ratelimit.New(zap.NewNop(), cfg.RateLimit)
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	r := &reloader{
+		logger:  zap.New(core),
+		src:     src,
+		cfg:     cfg,
+		level:   zap.NewAtomicLevel(),
+		db:      sqlx.NewDb(mockDB, "sqlmock"),
+		limiter: limiter,
+	}
+
+	flags["LOG_LEVEL"] = "warn"
+	flags["DB_MAX_OPEN_CONNS"] = "20"
+	flags["RATE_LIMIT_DEFAULT"] = "100/1m"
+	flags["RATE_LIMIT_ROUTES"] = "GET /v1/users:600/1m"
+	flags["RATE_LIMIT_IP"] = "60/1m"
+	flags["SERVER_ADDR"] = ":9000"
+
+	require.NoError(t, r.reload(context.Background()))
+
+	assert.Equal(t, zapcore.WarnLevel, r.level.Level())
+	assert.Equal(t, 20, r.cfg.DB.MaxOpenConns)
+	assert.Equal(t, "100/1m", r.cfg.RateLimit.Default)
+	assert.Equal(t, map[string]string{"GET /v1/users": "600/1m"}, r.cfg.RateLimit.Routes)
+	assert.Equal(t, "60/1m", r.cfg.RateLimit.IP)
+	assert.Equal(t, ":8000", r.cfg.ServerAddr, "needs a restart")
+
+	assert.Len(t, logs.FilterMessage("config setting was reloaded").All(), 5)
+	assert.Len(t, logs.FilterMessage("config setting changed, restart to apply").All(), 1)
+
+	// the applied settings are not reported again.
+	flags["SERVER_ADDR"] = ":8000"
+
+	logs.TakeAll()
+	require.NoError(t, r.reload(context.Background()))
+	assert.Len(t, logs.FilterMessage("config was reloaded without changes").All(), 1)
+
+	flags["RATE_LIMIT_IP"] = "fast"
+
+	assert.ErrorContains(t, r.reload(context.Background()), "rate limit: ip:")
+	assert.Equal(t, "60/1m", r.cfg.RateLimit.IP)
+}
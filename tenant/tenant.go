@@ -0,0 +1,81 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+// Header is the request header carrying the tenant identification.
+const Header = "X-Tenant-ID"
+
+// service error codes.
+const (
+	InvalidTenantID = "INVALID_TENANT_ID"
+	TenantRequired  = "TENANT_REQUIRED"
+)
+
+// ErrMissing is returned when the context does not carry a tenant.
+var ErrMissing = errors.New("tenant is not specified")
+
+type ctxKey struct{}
+
+// WithID returns a copy of the context carrying the tenant id.
+func WithID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant id stored in the context.
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(ctxKey{}).(uuid.UUID)
+	return id, ok
+}
+
+// Resolver extracts the tenant id from the request, ok is false when the request does not carry it.
+type Resolver func(r *http.Request) (id uuid.UUID, ok bool, err error)
+
+// FromHeader resolves the tenant from the X-Tenant-ID header.
+func FromHeader(r *http.Request) (uuid.UUID, bool, error) {
+	raw := r.Header.Get(Header)
+	if raw == "" {
+		return uuid.Nil, false, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	return id, true, nil
+}
+
+// Middleware puts the tenant found by the first successful resolver into the request context,
+// requests without a tenant are rejected.
+func Middleware(logger *zap.Logger, resolvers ...Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, resolve := range resolvers {
+				id, ok, err := resolve(r)
+				if err != nil {
+					logger.Warn("could not resolve tenant", zap.Error(err))
+					apperr.Write(w, r, logger, apperr.NewBadRequest(InvalidTenantID, err.Error()))
+
+					return
+				}
+
+				if ok {
+					next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+					return
+				}
+			}
+
+			logger.Warn("tenant not specified", zap.String("path", r.URL.Path))
+			apperr.Write(w, r, logger, apperr.NewBadRequest(TenantRequired, ErrMissing.Error()))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package tenant
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMiddleware(t *testing.T) {
+	fixed := func(id uuid.UUID) Resolver {
+		return func(r *http.Request) (uuid.UUID, bool, error) {
+			return id, true, nil
+		}
+	}
+
+	tests := []struct {
+		name         string
+		header       string
+		resolvers    []Resolver
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name:         "header",
+			header:       "5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d",
+			resolvers:    []Resolver{FromHeader},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d"),
+		},
+		{
+			name:         "first resolver wins",
+			header:       "5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d",
+			resolvers:    []Resolver{fixed(uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")), FromHeader},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+		},
+		{
+			name:         "invalid header",
+			header:       "invalid",
+			resolvers:    []Resolver{FromHeader},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/users","code":"INVALID_TENANT_ID"}`),
+		},
+		{
+			name:         "missing",
+			resolvers:    []Resolver{FromHeader},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"tenant is not specified","instance":"/v1/users","code":"TENANT_REQUIRED"}`),
+		},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := FromContext(r.Context())
+		_, _ = w.Write([]byte(id.String()))
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+
+			Middleware(zap.NewNop(), tt.resolvers...)(next).ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
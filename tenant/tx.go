@@ -0,0 +1,39 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// InTx runs fn in a transaction bound to the tenant from the context.
+// PostgreSQL row-level security policies read the tenant from the app.tenant_id setting,
+// so the queries inside fn can never see rows of another tenant.
+func InTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return ErrMissing
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('app.tenant_id', $1, true)", id.String()); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("set tenant: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	db := sqlx.NewDb(mockDB, "sqlmock")
+	ctx := WithID(context.Background(), uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d"))
+	setConfig := strings.NewReplacer("$", "\\$", "(", "\\(", ")", "\\)").
+		Replace(`SELECT set_config('app.tenant_id', $1, true)`)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		setup   func()
+		fnErr   error
+		wantErr error
+	}{
+		{
+			name: "success",
+			ctx:  ctx,
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(setConfig).
+					WithArgs("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "fn error",
+			ctx:  ctx,
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(setConfig).
+					WithArgs("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectRollback()
+			},
+			fnErr:   errors.New("exec: some err"),
+			wantErr: errors.New("exec: some err"),
+		},
+		{
+			name: "set config error",
+			ctx:  ctx,
+			setup: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(setConfig).
+					WithArgs("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d").
+					WillReturnError(errors.New("some err"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("set tenant: some err"),
+		},
+		{
+			name:    "missing tenant",
+			ctx:     context.Background(),
+			setup:   func() {},
+			wantErr: ErrMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+
+			err := InTx(tt.ctx, db, func(tx *sqlx.Tx) error {
+				return tt.fnErr
+			})
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
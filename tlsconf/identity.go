@@ -0,0 +1,57 @@
+package tlsconf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Identity is the client of the verified TLS certificate.
+type Identity struct {
+	Subject     string
+	DNSNames    []string
+	URIs        []string
+	Serial      string
+	Fingerprint string
+}
+
+type ctxKey struct{}
+
+// WithIdentity returns a copy of the context carrying the client identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// IdentityFromContext returns the client identity stored in the context.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(*Identity)
+	return id, ok
+}
+
+// Middleware stores the identity of the verified client certificate in the request context,
+// unverified certificates are ignored.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cert := r.TLS.VerifiedChains[0][0]
+		sum := sha256.Sum256(cert.Raw)
+
+		id := Identity{
+			Subject:     cert.Subject.CommonName,
+			DNSNames:    cert.DNSNames,
+			Serial:      cert.SerialNumber.String(),
+			Fingerprint: hex.EncodeToString(sum[:]),
+		}
+
+		for _, u := range cert.URIs {
+			id.URIs = append(id.URIs, u.String())
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), &id)))
+	})
+}
@@ -0,0 +1,220 @@
+// Package tlsconf builds the server TLS configuration, certificates are reloaded when their files change.
+package tlsconf
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/config"
+)
+
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// modernCiphers are the TLS 1.2 suites with forward secrecy and AEAD, TLS 1.3 suites are not configurable.
+var modernCiphers = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// Reloader serves the certificate and the client CA bundle of the files,
+// handshakes after a reload use the new ones.
+type Reloader struct {
+	logger *zap.Logger
+	cfg    config.TLSCfg
+	base   *tls.Config
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewReloader creates new Reloader and loads the files of the config.
+func NewReloader(logger *zap.Logger, cfg config.TLSCfg) (*Reloader, error) {
+	minVersion, ok := versions[cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unknown min version %q", cfg.MinVersion)
+	}
+
+	base := &tls.Config{MinVersion: minVersion}
+
+	switch cfg.Ciphers {
+	case "default":
+	case "modern":
+		base.CipherSuites = modernCiphers
+	case "compat":
+		for _, s := range tls.CipherSuites() {
+			base.CipherSuites = append(base.CipherSuites, s.ID)
+		}
+	default:
+		return nil, fmt.Errorf("unknown cipher policy %q", cfg.Ciphers)
+	}
+
+	switch {
+	case cfg.ClientAuth == "require":
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	case cfg.ClientAuth == "request" && cfg.ClientCAFile != "":
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+	case cfg.ClientAuth == "request":
+		base.ClientAuth = tls.RequestClientCert
+	}
+
+	r := &Reloader{logger: logger, cfg: cfg, base: base}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Config returns the server TLS configuration, the certificate is resolved at every handshake.
+func (r *Reloader) Config() *tls.Config {
+	cfg := r.base.Clone()
+	cfg.GetCertificate = r.getCertificate
+	cfg.GetConfigForClient = r.configForClient
+
+	return cfg
+}
+
+func (r *Reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// configForClient applies the current client CA bundle to the handshake.
+func (r *Reloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cfg := r.base.Clone()
+	cfg.Certificates = []tls.Certificate{*r.cert}
+	cfg.ClientCAs = r.clientCA
+
+	return cfg, nil
+}
+
+// Watch reloads the files when their modification time changes until the context is done.
+// Invalid files are logged and the previous certificates stay in effect.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.reload()
+			if err != nil {
+				r.logger.Error("could not reload tls certificates, keeping the previous ones", zap.Error(err))
+				continue
+			}
+
+			if reloaded {
+				r.logger.Info("tls certificates were reloaded", zap.String("cert", r.cfg.CertFile))
+			}
+		}
+	}
+}
+
+func (r *Reloader) reload() (bool, error) {
+	modTimes, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := equalTimes(modTimes, r.modTimes)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("load key pair: %w", err)
+	}
+
+	var clientCA *x509.CertPool
+
+	if r.cfg.ClientCAFile != "" {
+		if clientCA, err = loadPool(r.cfg.ClientCAFile); err != nil {
+			return false, err
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = clientCA
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+func (r *Reloader) stat() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, 3)
+
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat: %w", err)
+		}
+
+		modTimes[path] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
+
+func equalTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if !v.Equal(b[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read client ca: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("client ca: no certificates found")
+	}
+
+	return pool, nil
+}
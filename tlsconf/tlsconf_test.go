@@ -0,0 +1,201 @@
+package tlsconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/config"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCert(t *testing.T, cn string, serial int64, parent *testCert, server bool) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	switch {
+	case parent == nil:
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	case server:
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	default:
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		tmpl.DNSNames = []string{cn + ".example.org"}
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) tls(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(c.pem, c.keyPEM(t))
+	require.NoError(t, err)
+
+	return cert
+}
+
+func writeFiles(t *testing.T, cfg config.TLSCfg, server, ca *testCert, modTime time.Time) {
+	t.Helper()
+
+	files := map[string][]byte{cfg.CertFile: server.pem, cfg.KeyFile: server.keyPEM(t), cfg.ClientCAFile: ca.pem}
+
+	for path, data := range files {
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TLSCfg{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:   "require",
+		MinVersion:   "1.2",
+		Ciphers:      "modern",
+	}
+
+	ca := newCert(t, "ca", 1, nil, false)
+	modTime := time.Now().Add(-time.Minute)
+
+	writeFiles(t, cfg, newCert(t, "server", 2, ca, true), ca, modTime)
+
+	r, err := NewReloader(zap.NewNop(), cfg)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := IdentityFromContext(r.Context())
+		if !ok {
+			http.Error(w, "no identity", http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = io.WriteString(w, id.Subject+" "+id.DNSNames[0]+" "+id.Serial)
+	})))
+	srv.TLS = r.Config()
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	client := func(cert *testCert) *http.Client {
+		tlsCfg := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+		if cert != nil {
+			tlsCfg.Certificates = []tls.Certificate{cert.tls(t)}
+		}
+
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+	}
+
+	get := func(c *http.Client) (*http.Response, string, error) {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			return nil, "", err
+		}
+
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+
+		return resp, string(body), err
+	}
+
+	resp, body, err := get(client(newCert(t, "alice", 10, ca, false)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "alice alice.example.org 10", body)
+	assert.Equal(t, "server", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+	_, _, err = get(client(nil))
+	assert.Error(t, err, "client certificate is required")
+
+	_, _, err = get(client(newCert(t, "mallory", 11, newCert(t, "other-ca", 12, nil, false), false)))
+	assert.Error(t, err, "client certificate of unknown CA")
+
+	reloaded, err := r.reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// the files are rotated with the new CA.
+	newCA := newCert(t, "new-ca", 20, nil, false)
+	writeFiles(t, cfg, newCert(t, "rotated", 21, newCA, true), newCA, modTime.Add(time.Second))
+
+	reloaded, err = r.reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	roots.AddCert(newCA.cert)
+
+	resp, body, err = get(client(newCert(t, "bob", 22, newCA, false)))
+	require.NoError(t, err)
+	assert.Equal(t, "bob bob.example.org 22", body)
+	assert.Equal(t, "rotated", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+	// an invalid key keeps the previous certificate.
+	require.NoError(t, os.WriteFile(cfg.KeyFile, []byte("invalid"), 0o600))
+
+	_, err = r.reload()
+	assert.ErrorContains(t, err, "load key pair")
+
+	resp, _, err = get(client(newCert(t, "bob", 23, newCA, false)))
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", resp.TLS.PeerCertificates[0].Subject.CommonName)
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ihippik/template-service/config"
+)
+
+func TestSetup(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	_, err := Setup(context.Background(), config.TracingCfg{Exporter: "jaeger"}, "v1")
+	assert.EqualError(t, err, "jaeger exporter: unknown exporter")
+
+	shutdown, err := Setup(context.Background(), config.TracingCfg{Exporter: ExporterNone}, "v1")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	file := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdown, err = Setup(context.Background(), config.TracingCfg{
+		Exporter:    ExporterFile,
+		File:        file,
+		SampleRatio: 1,
+		ServiceName: "template-service",
+	}, "v1")
+	require.NoError(t, err)
+
+	_, span := Start(context.Background(), "user.Service.GetUser")
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"user.Service.GetUser"`)
+	assert.Contains(t, string(data), `"Value":"template-service"`)
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	op := func(ctx context.Context, fail bool) (err error) {
+		_, span := Start(ctx, "op")
+		defer End(span, &err)
+
+		if fail {
+			return errors.New("some err")
+		}
+
+		return nil
+	}
+
+	assert.NoError(t, op(context.Background(), false))
+	assert.Error(t, op(context.Background(), true))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "some err", spans[1].Status().Description)
+	assert.Len(t, spans[1].Events(), 1)
+}
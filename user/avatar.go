@@ -0,0 +1,105 @@
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // register gif decoder.
+	_ "image/jpeg" // register jpeg decoder.
+	"image/png"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+)
+
+// AvatarSizes lists the generated square thumbnail sizes in pixels, the last one is the default.
+var AvatarSizes = []int{64, 128, 256}
+
+// maxAvatarPixels protects from decompression bombs, the decoded image takes up to 16 MB.
+const maxAvatarPixels = 4 << 20
+
+var avatarContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+func defaultAvatarSize() int {
+	return AvatarSizes[len(AvatarSizes)-1]
+}
+
+func isAvatarSize(size int) bool {
+	for _, s := range AvatarSizes {
+		if s == size {
+			return true
+		}
+	}
+
+	return false
+}
+
+// avatarKey is the storage key of the thumbnail, the tenant comes first as user ids are looked up by tenant.
+func avatarKey(tenantID, id uuid.UUID, size int) string {
+	return fmt.Sprintf("avatars/%s/%s/%d.png", tenantID, id, size)
+}
+
+// avatarURLs builds relative avatar URLs, the version parameter busts caches after re-upload.
+func avatarURLs(u *User) map[string]string {
+	if u.AvatarUpdatedAt == nil {
+		return nil
+	}
+
+	urls := make(map[string]string, len(AvatarSizes))
+	version := strconv.FormatInt(u.AvatarUpdatedAt.UnixNano(), 10)
+
+	for _, size := range AvatarSizes {
+		urls[strconv.Itoa(size)] = fmt.Sprintf("/v1/users/%s/avatar?size=%d&v=%s", u.ID, size, version)
+	}
+
+	return urls
+}
+
+// makeThumbnails crops the image to the centered square and scales it to every avatar size.
+func makeThumbnails(data []byte) (map[int][]byte, error) {
+	if ct := http.DetectContentType(data); !avatarContentTypes[ct] {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedAvatar, ct)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+
+	if cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, fmt.Errorf("image %dx%d exceeds %d pixels", cfg.Width, cfg.Height, maxAvatarPixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	offset := image.Pt((bounds.Dx()-side)/2, (bounds.Dy()-side)/2)
+	square := image.Rectangle{Min: bounds.Min.Add(offset), Max: bounds.Min.Add(offset).Add(image.Pt(side, side))}
+
+	thumbs := make(map[int][]byte, len(AvatarSizes))
+
+	for _, size := range AvatarSizes {
+		dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, square, draw.Src, nil)
+
+		var buf bytes.Buffer
+
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, fmt.Errorf("encode: %w", err)
+		}
+
+		thumbs[size] = buf.Bytes()
+	}
+
+	return thumbs, nil
+}
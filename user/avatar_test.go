@@ -0,0 +1,106 @@
+package user
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+// grayImage encodes a blank image, large ones stay small on the wire.
+func grayImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+
+	return buf.Bytes()
+}
+
+func Test_makeThumbnails(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "landscape png",
+			data:    testImage(t, 300, 200),
+			wantErr: nil,
+		},
+		{
+			name:    "unsupported type",
+			data:    []byte("%PDF-1.4"),
+			wantErr: errors.New("unsupported image type: application/pdf"),
+		},
+		{
+			name:    "too many pixels",
+			data:    grayImage(t, 4096, 1025),
+			wantErr: errors.New("image 4096x1025 exceeds 4194304 pixels"),
+		},
+		{
+			name:    "broken image",
+			data:    testImage(t, 10, 10)[:40],
+			wantErr: errors.New("decode: unexpected EOF"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := makeThumbnails(tt.data)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+
+			assert.NoError(t, tt.wantErr)
+			assert.Len(t, got, len(AvatarSizes))
+
+			for _, size := range AvatarSizes {
+				cfg, err := png.DecodeConfig(bytes.NewReader(got[size]))
+				assert.NoError(t, err)
+				assert.Equal(t, size, cfg.Width)
+				assert.Equal(t, size, cfg.Height)
+			}
+		})
+	}
+}
+
+func Test_avatarURLs(t *testing.T) {
+	id := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+
+	assert.Nil(t, avatarURLs(&User{ID: id}))
+	assert.Equal(
+		t,
+		map[string]string{
+			"64":  "/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/avatar?size=64&v=1659312000123456000",
+			"128": "/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/avatar?size=128&v=1659312000123456000",
+			"256": "/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/avatar?size=256&v=1659312000123456000",
+		},
+		avatarURLs(&User{ID: id, AvatarUpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 123456000, time.UTC))}),
+	)
+}
@@ -3,18 +3,29 @@ package user
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 
 	"github.com/goccy/go-json"
 	"github.com/google/uuid"
-	"github.com/gorilla/mux"
 	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/apperr"
+	"github.com/ihippik/template-service/blob"
+	"github.com/ihippik/template-service/fieldmask"
 )
 
 type service interface {
 	GetUser(ctx context.Context, id uuid.UUID) (*User, error)
-	ListUser(ctx context.Context) ([]*User, error)
+	ListUser(ctx context.Context, sel Selector) ([]*User, error)
 	UpdateUser(ctx context.Context, id uuid.UUID, dto DTO) (*User, error)
+	UpdateLabels(ctx context.Context, id uuid.UUID, labels Labels) (*User, error)
+	UploadAvatar(ctx context.Context, id uuid.UUID, r io.Reader) (*User, error)
+	GetAvatar(ctx context.Context, id uuid.UUID, size int) (*blob.Object, error)
 	CreateUser(ctx context.Context, dto DTO) (*User, error)
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 }
@@ -22,10 +33,11 @@ type service interface {
 type Endpoint struct {
 	logger *zap.Logger
 	svc    service
+	mask   fieldmask.Policy
 }
 
-func NewEndpoint(logger *zap.Logger, svc service) *Endpoint {
-	return &Endpoint{logger: logger, svc: svc}
+func NewEndpoint(logger *zap.Logger, svc service, mask fieldmask.Policy) *Endpoint {
+	return &Endpoint{logger: logger, svc: svc, mask: mask}
 }
 
 type response struct {
@@ -36,39 +48,49 @@ type response struct {
 // @Title List
 // @Tags User
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Description list user
 // @Summary fetch user
 // @Success 200 {object} response
-// @Failure 500 {object} ServiceError
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 500 {object} apperr.Problem
+// @Param selector query string false "Label selector, e.g. team=core,env!=prod"
 // @Router /v1/users [GET]
 func (e *Endpoint) ListUsers(w http.ResponseWriter, r *http.Request) {
 	var resp response
 
 	w.Header().Set("Content-Type", "application/json")
 
-	models, err := e.svc.ListUser(r.Context())
+	sel, err := ParseSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		e.logger.Warn("could not parse label selector", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidSelector, err.Error()))
+
+		return
+	}
+
+	models, err := e.svc.ListUser(r.Context(), sel)
 	if err != nil {
-		e.writeErr(w, err)
+		e.writeErr(w, r, err)
 		return
 	}
 
 	resp.Data = models
-	e.writeResp(w, resp)
+	e.writeResp(w, r, resp)
 }
 
 // CreateUser http create user handler.
 // @Title Create
 // @Tags User
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Description create user by id
 // @Summary create user
 // @Success 200 {object} response
-// @Failure 400 {object} ServiceError
-// @Failure 422 {object} ServiceError
-// @Failure 404 {object} ServiceError
-// @Failure 500 {object} ServiceError
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Param model body DTO true "New model"
 // @Router /v1/users [POST]
 func (e *Endpoint) CreateUser(w http.ResponseWriter, r *http.Request) {
@@ -82,35 +104,35 @@ func (e *Endpoint) CreateUser(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&dto)
 	if err != nil {
 		e.logger.Warn("decode user data", zap.Error(err))
-		e.writeErr(w, newBadRequest(InvalidUserData, err.Error()))
+		e.writeErr(w, r, newDecodeErr(InvalidUserData, &dto, err))
 
 		return
 	}
 
 	model, err := e.svc.CreateUser(r.Context(), dto)
 	if err != nil {
-		e.writeErr(w, err)
+		e.writeErr(w, r, err)
 		return
 	}
 
 	resp.Data = append(resp.Data, model)
 
 	w.WriteHeader(http.StatusCreated)
-	e.writeResp(w, resp)
+	e.writeResp(w, r, resp)
 }
 
 // UpdateUser http update user handler.
 // @Title Update
 // @Tags User
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Description update user by id
 // @Summary update user
 // @Success 200 {object} response
-// @Failure 400 {object} ServiceError
-// @Failure 422 {object} ServiceError
-// @Failure 404 {object} ServiceError
-// @Failure 500 {object} ServiceError
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Param id path string true "User ID"
 // @Param model body DTO true "New model"
 // @Router /v1/users/{id} [PUT]
@@ -122,63 +144,221 @@ func (e *Endpoint) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-
-	id, err := uuid.Parse(vars["id"])
+	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
 		e.logger.Warn("could not parse user id", zap.Error(err))
-		e.writeErr(w, newBadRequest(InvalidUserID, err.Error()))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
 
 		return
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
 		e.logger.Warn("decode user data", zap.Error(err))
-		e.writeErr(w, newBadRequest(InvalidUserData, err.Error()))
+		e.writeErr(w, r, newDecodeErr(InvalidUserData, &dto, err))
 
 		return
 	}
 
 	model, err := e.svc.UpdateUser(r.Context(), id, dto)
 	if err != nil {
-		e.writeErr(w, err)
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = append(resp.Data, model)
+
+	e.writeResp(w, r, resp)
+}
+
+// UpdateLabels http replace user labels handler.
+// @Title UpdateLabels
+// @Tags User
+// @Accept json
+// @Produce json,application/problem+json
+// @Description replace user labels
+// @Summary update user labels
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param id path string true "User ID"
+// @Param labels body Labels true "New labels"
+// @Router /v1/users/{id}/labels [PUT]
+func (e *Endpoint) UpdateLabels(w http.ResponseWriter, r *http.Request) {
+	var (
+		labels Labels
+		resp   response
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		e.logger.Warn("could not parse user id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
+
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&labels); err != nil {
+		e.logger.Warn("decode user labels", zap.Error(err))
+		e.writeErr(w, r, newDecodeErr(InvalidUserData, &labels, err))
+
+		return
+	}
+
+	model, err := e.svc.UpdateLabels(r.Context(), id, labels)
+	if err != nil {
+		e.writeErr(w, r, err)
 		return
 	}
 
 	resp.Data = append(resp.Data, model)
 
-	e.writeResp(w, resp)
+	e.writeResp(w, r, resp)
+}
+
+// UploadAvatar http upload user avatar handler.
+// @Title UploadAvatar
+// @Tags User
+// @Accept multipart/form-data,image/jpeg,image/png,image/gif
+// @Produce json,application/problem+json
+// @Description upload user avatar as a raw image body or as the "avatar" multipart form field
+// @Summary upload user avatar
+// @Success 200 {object} response
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 413 {object} apperr.Problem
+// @Failure 415 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 500 {object} apperr.Problem
+// @Param id path string true "User ID"
+// @Router /v1/users/{id}/avatar [PUT]
+func (e *Endpoint) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	var resp response
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		e.logger.Warn("could not parse user id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
+
+		return
+	}
+
+	body := io.Reader(r.Body)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		part, err := avatarPart(r)
+		if err != nil {
+			e.logger.Warn("could not read avatar form", zap.Error(err))
+			e.writeErr(w, r, newBadRequest(InvalidAvatar, err.Error()))
+
+			return
+		}
+
+		defer part.Close()
+
+		body = part
+	}
+
+	model, err := e.svc.UploadAvatar(r.Context(), id, body)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	resp.Data = append(resp.Data, model)
+
+	e.writeResp(w, r, resp)
+}
+
+// GetAvatar http get user avatar handler.
+// @Title GetAvatar
+// @Tags User
+// @Produce png,application/problem+json
+// @Description get user avatar thumbnail
+// @Summary get user avatar
+// @Success 200 {file} binary
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Param id path string true "User ID"
+// @Param size query int false "Thumbnail size: 64, 128 or 256"
+// @Router /v1/users/{id}/avatar [GET]
+func (e *Endpoint) GetAvatar(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		e.logger.Warn("could not parse user id", zap.Error(err))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
+
+		return
+	}
+
+	size := defaultAvatarSize()
+
+	if raw := r.URL.Query().Get("size"); raw != "" {
+		if size, err = strconv.Atoi(raw); err != nil {
+			e.logger.Warn("could not parse avatar size", zap.Error(err))
+			e.writeErr(w, r, newBadRequest(InvalidAvatarSize, err.Error()))
+
+			return
+		}
+	}
+
+	obj, err := e.svc.GetAvatar(r.Context(), id, size)
+	if err != nil {
+		e.writeErr(w, r, err)
+		return
+	}
+
+	defer obj.Close()
+
+	w.Header().Set("Content-Type", "image/png")
+	// the avatar is visible to the tenant only, so shared caches must not keep it,
+	// the versioned URL of the user never changes its content.
+	cacheControl := "private, max-age=86400"
+	if r.URL.Query().Has("v") {
+		cacheControl += ", immutable"
+	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, obj.ModTime.UnixNano(), obj.Size))
+
+	http.ServeContent(w, r, "", obj.ModTime, obj)
 }
 
 // GetUser http get user handler.
 // @Title Get
 // @Tags User
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Description get user by id
 // @Summary get user
 // @Success 200 {object} response
-// @Failure 400 {object} ServiceError
-// @Failure 404 {object} ServiceError
-// @Failure 500 {object} ServiceError
+// @Failure 400 {object} apperr.Problem{errors=[]apperr.FieldError}
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Param id path string true "User ID"
 // @Router /v1/users/{id} [GET]
 func (e *Endpoint) GetUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-
-	id, err := uuid.Parse(vars["id"])
+	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
 		e.logger.Warn("could not parse user id", zap.Error(err))
-		e.writeErr(w, newBadRequest(InvalidUserID, err.Error()))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
 
 		return
 	}
 
 	model, err := e.svc.GetUser(r.Context(), id)
 	if err != nil {
-		e.writeErr(w, err)
+		e.writeErr(w, r, err)
 		return
 	}
 
@@ -186,35 +366,34 @@ func (e *Endpoint) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	resp.Data = append(resp.Data, model)
 
-	e.writeResp(w, resp)
+	e.writeResp(w, r, resp)
 }
 
 // DeleteUser http delete user handler.
 // @Title Delete
 // @Tags User
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Description delete user by id
 // @Summary delete user
 // @Success 200 {object} response
-// @Failure 500 {object} ServiceError
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Param id path string true "User ID"
 // @Router /v1/users/{id} [DELETE]
 func (e *Endpoint) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-
-	id, err := uuid.Parse(vars["id"])
+	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
 		e.logger.Warn("could not parse user id", zap.Error(err))
-		e.writeErr(w, newBadRequest(InvalidUserID, err.Error()))
+		e.writeErr(w, r, newBadRequest(InvalidUserID, err.Error()))
 
 		return
 	}
 
 	if err := e.svc.DeleteUser(r.Context(), id); err != nil {
-		e.writeErr(w, err)
+		e.writeErr(w, r, err)
 		return
 	}
 
@@ -222,47 +401,54 @@ func (e *Endpoint) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	resp.Data = []*User{}
 
-	e.writeResp(w, resp)
+	e.writeResp(w, r, resp)
 }
 
-func (e *Endpoint) writeResp(w http.ResponseWriter, uData any) {
-	data, err := json.Marshal(uData)
+// avatarPart finds the avatar file in the multipart form without buffering the whole request.
+func avatarPart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
 	if err != nil {
-		e.writeErr(w, newInternalServer(InternalServerError, err.Error()))
-		e.logger.Warn("marshal data", zap.Error(err))
-		return
+		return nil, err
 	}
 
-	if _, err = w.Write(data); err != nil {
-		e.logger.Error("write error", zap.Error(err))
-	}
-}
-
-func (e *Endpoint) writeErr(w http.ResponseWriter, err error) {
-	var svcErr *ServiceError
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("avatar form field is missing")
+		}
 
-	if errors.As(err, &svcErr) {
-		data, err := json.Marshal(svcErr)
 		if err != nil {
-			e.logger.Error("marshal server err", zap.Error(err))
-			return
+			return nil, err
 		}
 
-		w.WriteHeader(svcErr.HTTPCode)
-
-		if _, err = w.Write(data); err != nil {
-			e.logger.Error("write error", zap.Error(err))
+		if part.FormName() == "avatar" {
+			return part, nil
 		}
 
-		return
+		_ = part.Close()
 	}
+}
 
-	data, err := json.Marshal(errInternalServer)
+// writeResp writes users masked according to the policy for the caller.
+func (e *Endpoint) writeResp(w http.ResponseWriter, r *http.Request, uData any) {
+	data, err := json.Marshal(uData)
 	if err != nil {
-		e.logger.Error("marshal internal err", zap.Error(err))
+		e.writeErr(w, r, newInternalServer(InternalServerError, err.Error()))
+		e.logger.Warn("marshal data", zap.Error(err))
+		return
+	}
+
+	if data, err = e.mask.ForContext(r.Context()).MaskData(data); err != nil {
+		e.writeErr(w, r, newInternalServer(InternalServerError, err.Error()))
+		e.logger.Warn("mask data", zap.Error(err))
 		return
 	}
 
-	w.WriteHeader(http.StatusInternalServerError)
-	_, _ = w.Write(data)
+	if _, err = w.Write(data); err != nil {
+		e.logger.Error("write error", zap.Error(err))
+	}
+}
+
+func (e *Endpoint) writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	apperr.Write(w, r, e.logger, err)
 }
@@ -5,13 +5,14 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/goccy/go-json"
-	"github.com/gorilla/mux"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/ory/dockertest"
@@ -20,6 +21,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/ihippik/template-service/migrations"
+	"github.com/ihippik/template-service/tenant"
 )
 
 type RepositoryTestSuite struct {
@@ -90,13 +92,15 @@ func (s *RepositoryTestSuite) TearDownSuite() {
 func (s *RepositoryTestSuite) TestEndpoint() {
 	logger := zap.NewNop()
 	r := NewRepository(s.db)
-	svc := NewService(nil, logger, r)
-	endpoint := NewEndpoint(logger, svc)
+	svc := NewService(nil, logger, r, nil, nil)
+	endpoint := NewEndpoint(logger, svc, nil)
 
 	var dto = []byte(`{"lastName":"Rogozin","firstName":"Elon","birthday":"1971-06-28"}`)
 
+	ctx := tenant.WithID(context.Background(), uuid.New())
+
 	// create user
-	req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewReader(dto))
+	req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewReader(dto)).WithContext(ctx)
 	w := httptest.NewRecorder()
 	endpoint.CreateUser(w, req)
 
@@ -113,10 +117,9 @@ func (s *RepositoryTestSuite) TestEndpoint() {
 	assert.NoError(s.T(), err)
 
 	// get user
-	getReq := httptest.NewRequest(http.MethodPost, "/v1/users/id", bytes.NewReader(dto))
+	getReq := httptest.NewRequest(http.MethodGet, "/v1/users/"+resp.Data[0].ID.String(), nil).WithContext(ctx)
 	getW := httptest.NewRecorder()
-	getReq = mux.SetURLVars(getReq, map[string]string{"id": resp.Data[0].ID.String()})
-	endpoint.GetUser(getW, getReq)
+	serve("GET /v1/users/{id}", endpoint.GetUser, getW, getReq)
 
 	getRes := getW.Result()
 	defer getRes.Body.Close()
@@ -2,24 +2,37 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+
+	"github.com/ihippik/template-service/auth"
+	"github.com/ihippik/template-service/blob"
+	"github.com/ihippik/template-service/fieldmask"
+	"github.com/ihippik/template-service/rbac"
 )
 
+// serve routes the request through the pattern main registers the handler with.
+func serve(pattern string, h http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, h)
+	mux.ServeHTTP(w, r)
+}
+
 func TestEndpoint_ListUsers(t *testing.T) {
 	svc := new(MockServer)
 
 	setList := func(users []*User, err error) {
-		svc.On("ListUser", mock.Anything).Return(users, err).Once()
+		svc.On("ListUser", mock.Anything, Selector(nil)).Return(users, err).Once()
 	}
 
 	tests := []struct {
@@ -57,7 +70,7 @@ func TestEndpoint_ListUsers(t *testing.T) {
 				)
 			},
 			wantHTTPCode: http.StatusInternalServerError,
-			want:         []byte(`{"code":"INTERNAL_SERVER_ERROR","message":"internal server error"}`),
+			want:         []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users","code":"INTERNAL_SERVER_ERROR"}`),
 		},
 	}
 
@@ -142,7 +155,7 @@ func TestEndpoint_GetUser(t *testing.T) {
 				)
 			},
 			wantHTTPCode: http.StatusInternalServerError,
-			want:         []byte(`{"code":"INTERNAL_SERVER_ERROR","message":"internal server error"}`),
+			want:         []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608","code":"INTERNAL_SERVER_ERROR"}`),
 		},
 		{
 			name: "invalid id",
@@ -152,7 +165,7 @@ func TestEndpoint_GetUser(t *testing.T) {
 			setup: func() {
 			},
 			wantHTTPCode: http.StatusBadRequest,
-			want:         []byte(`{"code":"INVALID_USER_ID","message":"invalid UUID length: 7"}`),
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/users/invalid","code":"INVALID_USER_ID"}`),
 		},
 	}
 
@@ -167,11 +180,10 @@ func TestEndpoint_GetUser(t *testing.T) {
 
 			tt.setup()
 
-			req := httptest.NewRequest(http.MethodGet, "/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608", nil)
-			req = mux.SetURLVars(req, map[string]string{"id": tt.args.id})
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/"+tt.args.id, nil)
 			w := httptest.NewRecorder()
 
-			e.GetUser(w, req)
+			serve("GET /v1/users/{id}", e.GetUser, w, req)
 
 			res := w.Result()
 			defer res.Body.Close()
@@ -258,7 +270,7 @@ func TestEndpoint_UpdateUser(t *testing.T) {
 				)
 			},
 			wantHTTPCode: http.StatusInternalServerError,
-			want:         []byte(`{"code":"INTERNAL_SERVER_ERROR","message":"internal server error"}`),
+			want:         []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608","code":"INTERNAL_SERVER_ERROR"}`),
 		},
 		{
 			name: "invalid id",
@@ -269,7 +281,7 @@ func TestEndpoint_UpdateUser(t *testing.T) {
 			setup: func() {
 			},
 			wantHTTPCode: http.StatusBadRequest,
-			want:         []byte(`{"code":"INVALID_USER_ID","message":"invalid UUID length: 7"}`),
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/users/invalid","code":"INVALID_USER_ID"}`),
 		},
 		{
 			name: "invalid body",
@@ -280,7 +292,18 @@ func TestEndpoint_UpdateUser(t *testing.T) {
 			setup: func() {
 			},
 			wantHTTPCode: http.StatusBadRequest,
-			want:         []byte(`{"code":"INVALID_USER_DATA","message":"invalid character 'i' looking for beginning of value"}`),
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"body must be valid JSON","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608","code":"INVALID_USER_DATA","errors":[{"rule":"json","message":"body must be valid JSON"}]}`),
+		},
+		{
+			name: "invalid field type",
+			args: args{
+				id:  "ccae37ea-d41e-4371-a3a3-89203b9e2608",
+				dto: []byte(`{"lastName":"Rogozin","firstName":1}`),
+			},
+			setup: func() {
+			},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"firstName must be string","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608","code":"INVALID_USER_DATA","errors":[{"field":"firstName","rule":"type","param":"string","message":"firstName must be string"}]}`),
 		},
 	}
 
@@ -295,15 +318,10 @@ func TestEndpoint_UpdateUser(t *testing.T) {
 
 			tt.setup()
 
-			req := httptest.NewRequest(
-				http.MethodPut,
-				"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608",
-				bytes.NewReader(tt.args.dto),
-			)
-			req = mux.SetURLVars(req, map[string]string{"id": tt.args.id})
+			req := httptest.NewRequest(http.MethodPut, "/v1/users/"+tt.args.id, bytes.NewReader(tt.args.dto))
 			w := httptest.NewRecorder()
 
-			e.UpdateUser(w, req)
+			serve("PUT /v1/users/{id}", e.UpdateUser, w, req)
 
 			res := w.Result()
 			defer res.Body.Close()
@@ -385,7 +403,7 @@ func TestEndpoint_CreateUser(t *testing.T) {
 				)
 			},
 			wantHTTPCode: http.StatusInternalServerError,
-			want:         []byte(`{"code":"INTERNAL_SERVER_ERROR","message":"internal server error"}`),
+			want:         []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users","code":"INTERNAL_SERVER_ERROR"}`),
 		},
 		{
 			name: "invalid body",
@@ -395,7 +413,7 @@ func TestEndpoint_CreateUser(t *testing.T) {
 			setup: func() {
 			},
 			wantHTTPCode: http.StatusBadRequest,
-			want:         []byte(`{"code":"INVALID_USER_DATA","message":"invalid character 'i' looking for beginning of value"}`),
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"body must be valid JSON","instance":"/v1/users","code":"INVALID_USER_DATA","errors":[{"rule":"json","message":"body must be valid JSON"}]}`),
 		},
 	}
 
@@ -476,7 +494,7 @@ func TestEndpoint_DeleteUser(t *testing.T) {
 				)
 			},
 			wantHTTPCode: http.StatusInternalServerError,
-			want:         []byte(`{"code":"INTERNAL_SERVER_ERROR","message":"internal server error"}`),
+			want:         []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608","code":"INTERNAL_SERVER_ERROR"}`),
 		},
 		{
 			name: "invalid id",
@@ -486,7 +504,7 @@ func TestEndpoint_DeleteUser(t *testing.T) {
 			setup: func() {
 			},
 			wantHTTPCode: http.StatusBadRequest,
-			want:         []byte(`{"code":"INVALID_USER_ID","message":"invalid UUID length: 7"}`),
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid UUID length: 7","instance":"/v1/users/invalid","code":"INVALID_USER_ID"}`),
 		},
 	}
 
@@ -501,11 +519,10 @@ func TestEndpoint_DeleteUser(t *testing.T) {
 
 			tt.setup()
 
-			req := httptest.NewRequest(http.MethodDelete, "/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608", nil)
-			req = mux.SetURLVars(req, map[string]string{"id": tt.args.id})
+			req := httptest.NewRequest(http.MethodDelete, "/v1/users/"+tt.args.id, nil)
 			w := httptest.NewRecorder()
 
-			e.DeleteUser(w, req)
+			serve("DELETE /v1/users/{id}", e.DeleteUser, w, req)
 
 			res := w.Result()
 			defer res.Body.Close()
@@ -523,6 +540,7 @@ func TestNewEndpoint(t *testing.T) {
 	type args struct {
 		logger *zap.Logger
 		svc    service
+		mask   fieldmask.Policy
 	}
 
 	svc := new(MockServer)
@@ -537,17 +555,465 @@ func TestNewEndpoint(t *testing.T) {
 			args: args{
 				logger: zap.NewNop(),
 				svc:    svc,
+				mask:   fieldmask.Policy{fieldmask.Default: {"birthday": fieldmask.Omit}},
 			},
 			want: &Endpoint{
 				logger: zap.NewNop(),
 				svc:    svc,
+				mask:   fieldmask.Policy{fieldmask.Default: {"birthday": fieldmask.Omit}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, NewEndpoint(tt.args.logger, tt.args.svc, tt.args.mask), "NewEndpoint(%v, %v, %v)", tt.args.logger, tt.args.svc, tt.args.mask)
+		})
+	}
+}
+
+func TestEndpoint_ListUsersSelector(t *testing.T) {
+	svc := new(MockServer)
+
+	setList := func(sel Selector, users []*User, err error) {
+		svc.On("ListUser", mock.Anything, sel).Return(users, err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		selector     string
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name:     "success",
+			selector: "team%3Dcore%2Cenv%21%3Dprod",
+			setup: func() {
+				setList(
+					Selector{
+						{Key: "team", Op: OpEquals, Values: []string{"core"}},
+						{Key: "env", Op: OpNotEquals, Values: []string{"prod"}},
+					},
+					[]*User{
+						{
+							ID:        uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+							FirstName: "Elon",
+							LastName:  "Musk",
+							Birthday:  "1971-06-28",
+							Labels:    Labels{"team": "core"},
+							CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+						},
+					},
+					nil,
+				)
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte(`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","firstName":"Elon","lastName":"Musk","birthday":"1971-06-28","labels":{"team":"core"},"createdAt":"2022-11-17T20:00:00Z","updatedAt":null}]}`),
+		},
+		{
+			name:         "invalid selector",
+			selector:     "team%3Dcore%2C%2C",
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid selector \"team=core,,\": empty requirement","instance":"/v1/users","code":"INVALID_SELECTOR"}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users?selector="+tt.selector, nil)
+			w := httptest.NewRecorder()
+
+			e.ListUsers(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestEndpoint_UpdateLabels(t *testing.T) {
+	type args struct {
+		id     string
+		labels []byte
+	}
+
+	svc := new(MockServer)
+
+	setUpdateLabels := func(id uuid.UUID, labels Labels, user *User, err error) {
+		svc.On("UpdateLabels", mock.Anything, id, labels).Return(user, err).Once()
+	}
+
+	tests := []struct {
+		name         string
+		args         args
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "success",
+			args: args{
+				id:     "ccae37ea-d41e-4371-a3a3-89203b9e2608",
+				labels: []byte(`{"team":"core"}`),
+			},
+			setup: func() {
+				setUpdateLabels(
+					uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+					Labels{"team": "core"},
+					&User{
+						ID:        uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+						FirstName: "Elon",
+						LastName:  "Musk",
+						Birthday:  "1971-06-28",
+						Labels:    Labels{"team": "core"},
+						CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+					},
+					nil,
+				)
+			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte(`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","firstName":"Elon","lastName":"Musk","birthday":"1971-06-28","labels":{"team":"core"},"createdAt":"2022-11-17T20:00:00Z","updatedAt":null}]}`),
+		},
+		{
+			name: "invalid body",
+			args: args{
+				id:     "ccae37ea-d41e-4371-a3a3-89203b9e2608",
+				labels: []byte(`invalid`),
+			},
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"body must be valid JSON","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/labels","code":"INVALID_USER_DATA","errors":[{"rule":"json","message":"body must be valid JSON"}]}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/users/"+tt.args.id+"/labels", bytes.NewReader(tt.args.labels))
+			w := httptest.NewRecorder()
+
+			serve("PUT /v1/users/{id}/labels", e.UpdateLabels, w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestEndpoint_GetAvatar(t *testing.T) {
+	id := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+	modTime := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	svc := new(MockServer)
+
+	setGetAvatar := func(size int, obj *blob.Object, err error) {
+		svc.On("GetAvatar", mock.Anything, id, size).Return(obj, err).Once()
+	}
+
+	avatar := func() *blob.Object {
+		return &blob.Object{
+			ReadSeekCloser: nopSeekCloser{bytes.NewReader([]byte("avatar"))},
+			Size:           6,
+			ModTime:        modTime,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		ifNoneMatch  string
+		setup        func()
+		wantHTTPCode int
+		wantETag     string
+		wantCache    string
+		want         []byte
+	}{
+		{
+			name:  "success",
+			query: "?size=64&v=1659312000000000000",
+			setup: func() {
+				setGetAvatar(64, avatar(), nil)
+			},
+			wantHTTPCode: http.StatusOK,
+			wantETag:     `"17070f78fb8b0000-6"`,
+			wantCache:    "private, max-age=86400, immutable",
+			want:         []byte("avatar"),
+		},
+		{
+			name:        "not modified",
+			query:       "",
+			ifNoneMatch: `"17070f78fb8b0000-6"`,
+			setup: func() {
+				setGetAvatar(256, avatar(), nil)
+			},
+			wantHTTPCode: http.StatusNotModified,
+			wantETag:     `"17070f78fb8b0000-6"`,
+			wantCache:    "private, max-age=86400",
+			want:         []byte{},
+		},
+		{
+			name:         "invalid size",
+			query:        "?size=big",
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"strconv.Atoi: parsing \"big\": invalid syntax","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/avatar","code":"INVALID_AVATAR_SIZE"}`),
+		},
+		{
+			name:  "not found",
+			query: "?size=64",
+			setup: func() {
+				setGetAvatar(64, nil, newNotFoundErr(NotFound, "avatar not found"))
+			},
+			wantHTTPCode: http.StatusNotFound,
+			want:         []byte(`{"type":"about:blank","title":"Not Found","status":404,"detail":"avatar not found","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/avatar","code":"NOT_FOUND"}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/"+id.String()+"/avatar"+tt.query, nil)
+
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			w := httptest.NewRecorder()
+
+			serve("GET /v1/users/{id}/avatar", e.GetAvatar, w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+			assert.Equal(t, tt.wantETag, res.Header.Get("ETag"))
+			assert.Equal(t, tt.wantCache, res.Header.Get("Cache-Control"))
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestEndpoint_UploadAvatar(t *testing.T) {
+	id := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+
+	svc := new(MockServer)
+
+	setUploadAvatar := func(data []byte, user *User, err error) {
+		svc.On("UploadAvatar", mock.Anything, id, mock.Anything).
+			Run(func(args mock.Arguments) {
+				got, err := io.ReadAll(args.Get(2).(io.Reader))
+				assert.NoError(t, err)
+				assert.Equal(t, data, got)
+			}).
+			Return(user, err).
+			Once()
+	}
+
+	multipartBody := func(field string) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+
+		mw := multipart.NewWriter(&buf)
+		fw, err := mw.CreateFormFile(field, "avatar.png")
+		assert.NoError(t, err)
+
+		_, err = fw.Write([]byte("image"))
+		assert.NoError(t, err)
+		assert.NoError(t, mw.Close())
+
+		return &buf, mw.FormDataContentType()
+	}
+
+	tests := []struct {
+		name         string
+		body         func() (*bytes.Buffer, string)
+		setup        func()
+		wantHTTPCode int
+		want         []byte
+	}{
+		{
+			name: "raw body",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString("image"), "image/png"
+			},
+			setup: func() {
+				setUploadAvatar([]byte("image"), &User{ID: id, CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC)}, nil)
 			},
+			wantHTTPCode: http.StatusOK,
+			want:         []byte(`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","firstName":"","lastName":"","birthday":"","createdAt":"2022-11-17T20:00:00Z","updatedAt":null}]}`),
+		},
+		{
+			name: "multipart",
+			body: func() (*bytes.Buffer, string) {
+				return multipartBody("avatar")
+			},
+			setup: func() {
+				setUploadAvatar([]byte("image"), nil, newTooLargeErr(AvatarTooLarge, "avatar exceeds 1 bytes"))
+			},
+			wantHTTPCode: http.StatusRequestEntityTooLarge,
+			want:         []byte(`{"type":"about:blank","title":"Request Entity Too Large","status":413,"detail":"avatar exceeds 1 bytes","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/avatar","code":"AVATAR_TOO_LARGE"}`),
+		},
+		{
+			name: "multipart without avatar",
+			body: func() (*bytes.Buffer, string) {
+				return multipartBody("photo")
+			},
+			setup:        func() {},
+			wantHTTPCode: http.StatusBadRequest,
+			want:         []byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"avatar form field is missing","instance":"/v1/users/ccae37ea-d41e-4371-a3a3-89203b9e2608/avatar","code":"INVALID_AVATAR"}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svc.AssertExpectations(t)
+
+			tt.setup()
+
+			body, contentType := tt.body()
+
+			req := httptest.NewRequest(http.MethodPut, "/v1/users/"+id.String()+"/avatar", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+
+			serve("PUT /v1/users/{id}/avatar", e.UploadAvatar, w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantHTTPCode, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
+
+func TestEndpoint_ListUsersMasked(t *testing.T) {
+	svc := new(MockServer)
+
+	users := []*User{
+		{
+			ID:        uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+			FirstName: "Elon",
+			LastName:  "Musk",
+			Birthday:  "1971-06-28",
+			Labels:    Labels{"birthday": "secret"},
+			CreatedAt: time.Date(2022, 11, 17, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		roles     []string
+		principal *auth.Principal
+		want      []byte
+	}{
+		{
+			name: "default",
+			want: []byte(`{"data":[{"createdAt":"2022-11-17T20:00:00Z","firstName":"Elon","id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","labels":{"birthday":"secret"},"lastName":"Musk","updatedAt":null}]}`),
+		},
+		{
+			name:  "role",
+			roles: []string{rbac.RoleViewer},
+			want:  []byte(`{"data":[{"birthday":null,"createdAt":"2022-11-17T20:00:00Z","firstName":"Elon","id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","labels":{"birthday":"secret"},"lastName":"Musk","updatedAt":null}]}`),
+		},
+		{
+			name:      "scope",
+			roles:     []string{rbac.RoleViewer},
+			principal: &auth.Principal{Subject: "alice", Scopes: []string{"users:read:sensitive"}},
+			want:      []byte(`{"data":[{"id":"ccae37ea-d41e-4371-a3a3-89203b9e2608","firstName":"Elon","lastName":"Musk","birthday":"1971-06-28","labels":{"birthday":"secret"},"createdAt":"2022-11-17T20:00:00Z","updatedAt":null}]}`),
+		},
+	}
+
+	e := &Endpoint{
+		logger: zap.NewNop(),
+		svc:    svc,
+		mask: fieldmask.Policy{
+			fieldmask.Default:      {"birthday": fieldmask.Omit},
+			rbac.RoleViewer:        {"birthday": fieldmask.Redact},
+			"users:read:sensitive": {},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, NewEndpoint(tt.args.logger, tt.args.svc), "NewEndpoint(%v, %v)", tt.args.logger, tt.args.svc)
+			svc.On("ListUser", mock.Anything, Selector(nil)).Return(users, nil).Once()
+			defer svc.AssertExpectations(t)
+
+			ctx := rbac.WithRoles(context.Background(), tt.roles)
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users", nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			e.ListUsers(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			data, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, string(tt.want), string(data))
 		})
 	}
 }
@@ -3,49 +3,65 @@ package user
 import (
 	"errors"
 	"net/http"
+
+	"github.com/ihippik/template-service/apperr"
 )
 
 // service error codes.
 const (
 	InvalidUserID       = "INVALID_USER_ID"
 	InvalidUserData     = "INVALID_USER_DATA"
-	InternalServerError = "INTERNAL_SERVER_ERROR"
-	NotFound            = "NOT_FOUND"
-	ValidationError     = "VALIDATION_ERROR"
+	InvalidSelector     = "INVALID_SELECTOR"
+	InvalidAvatar       = "INVALID_AVATAR"
+	InvalidAvatarSize   = "INVALID_AVATAR_SIZE"
+	AvatarTooLarge      = "AVATAR_TOO_LARGE"
+	UnsupportedAvatar   = "UNSUPPORTED_AVATAR_TYPE"
+	Forbidden           = "FORBIDDEN"
+	InternalServerError = apperr.InternalServerError
+	NotFound            = apperr.NotFound
+	ValidationError     = apperr.ValidationError
 )
 
-var errNotExists = errors.New("not exists")
+var (
+	errNotExists         = errors.New("not exists")
+	errUnsupportedAvatar = errors.New("unsupported image type")
+)
 
 // ServiceError represent service custom error.
-type ServiceError struct {
-	HTTPCode int    `json:"-"`
-	Code     string `json:"code,omitempty"`
-	Message  string `json:"message,omitempty"`
+type ServiceError = apperr.ServiceError
+
+func newBadRequest(code, msg string) *ServiceError {
+	return apperr.NewBadRequest(code, msg)
 }
 
-// Error implement Error interface.
-func (e ServiceError) Error() string {
-	return e.Message
+func newInternalServer(code, msg string) *ServiceError {
+	return apperr.NewInternalServer(code, msg)
 }
 
-var errInternalServer = &ServiceError{
-	HTTPCode: http.StatusInternalServerError,
-	Code:     "INTERNAL_SERVER",
-	Message:  "internal server error",
+func newNotFoundErr(code, msg string) *ServiceError {
+	return apperr.NewNotFound(code, msg)
 }
 
-func newBadRequest(code, msg string) *ServiceError {
-	return &ServiceError{HTTPCode: http.StatusBadRequest, Code: code, Message: msg}
+func newValidationErr(code, msg string) *ServiceError {
+	return apperr.NewValidation(code, msg)
 }
 
-func newInternalServer(code, msg string) *ServiceError {
-	return &ServiceError{HTTPCode: http.StatusInternalServerError, Code: code, Message: msg}
+func newTooLargeErr(code, msg string) *ServiceError {
+	return apperr.New(http.StatusRequestEntityTooLarge, code, msg)
 }
 
-func newNotFoundErr(code, msg string) *ServiceError {
-	return &ServiceError{HTTPCode: http.StatusNotFound, Code: code, Message: msg}
+func newUnsupportedMediaErr(code, msg string) *ServiceError {
+	return apperr.New(http.StatusUnsupportedMediaType, code, msg)
 }
 
-func newValidationErr(code, msg string) *ServiceError {
-	return &ServiceError{HTTPCode: http.StatusUnprocessableEntity, Code: code, Message: msg}
+func newForbiddenErr(code, msg string) *ServiceError {
+	return apperr.New(http.StatusForbidden, code, msg)
+}
+
+func newDecodeErr(code string, dst any, err error) *ServiceError {
+	return apperr.NewDecode(code, dst, err)
+}
+
+func newFieldValidationErr(code string, err error) *ServiceError {
+	return apperr.NewFieldValidation(code, err)
 }
@@ -0,0 +1,145 @@
+package user
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/goccy/go-json"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+const (
+	maxLabelNameLen   = 63
+	maxLabelPrefixLen = 253
+	maxLabelValueLen  = 63
+)
+
+// validation rules of labels, their messages are in the apperr catalogs.
+const (
+	labelKeyRule   = "label_key"
+	labelValueRule = "label_value"
+)
+
+var (
+	labelNameRe   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	labelPrefixRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+)
+
+// Labels represent free-form key=value user labels.
+type Labels map[string]string
+
+// Validate check label keys and values, the syntax follows Kubernetes labels.
+// Invalid labels are reported as apperr.FieldErrors by the label key.
+func (l Labels) Validate() error {
+	keys := make([]string, 0, len(l))
+	for key := range l {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var errs apperr.FieldErrors
+
+	for _, key := range keys {
+		if err := validateLabelKey(key); err != nil {
+			errs = append(errs, apperr.FieldError{Field: key, Rule: labelKeyRule, Param: strconv.Itoa(maxLabelNameLen)})
+		}
+
+		if err := validateLabelValue(l[key]); err != nil {
+			errs = append(errs, apperr.FieldError{Field: key, Rule: labelValueRule, Param: strconv.Itoa(maxLabelValueLen)})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Scan implements sql.Scanner interface.
+func (l *Labels) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported labels type %T", src)
+	}
+
+	labels := make(Labels)
+
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return fmt.Errorf("unmarshal labels: %w", err)
+	}
+
+	if len(labels) == 0 {
+		labels = nil
+	}
+
+	*l = labels
+
+	return nil
+}
+
+// Value implements driver.Valuer interface.
+func (l Labels) Value() (driver.Value, error) {
+	if l == nil {
+		return []byte(`{}`), nil
+	}
+
+	return json.Marshal(l)
+}
+
+func validateLabelKey(key string) error {
+	prefix, name := "", key
+
+	for i := len(key) - 1; i >= 0; i-- {
+		if key[i] == '/' {
+			prefix, name = key[:i], key[i+1:]
+			break
+		}
+	}
+
+	if key != name {
+		if len(prefix) == 0 || len(prefix) > maxLabelPrefixLen || !labelPrefixRe.MatchString(prefix) {
+			return fmt.Errorf("invalid label key %q: prefix must be a DNS subdomain", key)
+		}
+	}
+
+	if len(name) == 0 || len(name) > maxLabelNameLen || !labelNameRe.MatchString(name) {
+		return fmt.Errorf(
+			"invalid label key %q: name must be 1-%d alphanumeric characters, '-', '_' or '.'",
+			key,
+			maxLabelNameLen,
+		)
+	}
+
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if len(value) > maxLabelValueLen || !labelNameRe.MatchString(value) {
+		return fmt.Errorf(
+			"invalid label value %q: must be empty or 1-%d alphanumeric characters, '-', '_' or '.'",
+			value,
+			maxLabelValueLen,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/apperr"
+)
+
+func TestLabels_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  Labels
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			labels: Labels{"team": "core", "example.org/tier": "", "env": "prod-1"},
+		},
+		{
+			name:   "invalid",
+			labels: Labels{"team": "core team", "-env": "prod", "Example.org/tier": "db"},
+			wantErr: apperr.FieldErrors{
+				{Field: "-env", Rule: labelKeyRule, Param: "63"},
+				{Field: "Example.org/tier", Rule: labelKeyRule, Param: "63"},
+				{Field: "team", Rule: labelValueRule, Param: "63"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.labels.Validate()
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
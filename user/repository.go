@@ -5,12 +5,17 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+
+	"github.com/ihippik/template-service/metrics"
+	"github.com/ihippik/template-service/tenant"
 )
 
 // Repository is a database PostgreSQL repository.
+// Every query runs in a transaction bound to the tenant from the context.
 type Repository struct {
 	db *sqlx.DB
 }
@@ -20,57 +25,82 @@ func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// List receive all user from the database.
-func (r *Repository) List(ctx context.Context) ([]*User, error) {
+// List receive all user matching the label selector from the database.
+func (r *Repository) List(ctx context.Context, sel Selector) (_ []*User, err error) {
+	defer metrics.ObserveQuery("user", "List", time.Now(), &err)
+
 	var models []*User
 
-	rows, err := r.db.QueryxContext(
-		ctx,
-		"SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users",
-	)
-	if err != nil {
-		return nil, fmt.Errorf("query: %w", err)
+	query := "SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users"
+
+	where, args := sel.where(1)
+	if where != "" {
+		query += " WHERE " + where
 	}
 
-	for rows.Next() {
-		var model User
+	err = tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		for rows.Next() {
+			var model User
+
+			if err := rows.StructScan(&model); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("scan: %w", err)
+			}
 
-		if err := rows.StructScan(&model); err != nil {
-			return nil, fmt.Errorf("scan: %w", err)
+			models = append(models, &model)
 		}
 
-		models = append(models, &model)
-	}
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("close: %w", err)
+		}
 
-	if err := rows.Close(); err != nil {
-		return nil, fmt.Errorf("close: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return models, nil
 }
 
 // Get receive user form the database by her id.
-func (r *Repository) Get(ctx context.Context, id uuid.UUID) (*User, error) {
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (_ *User, err error) {
+	defer metrics.ObserveQuery("user", "Get", time.Now(), &err)
+
 	var model User
 
-	err := r.db.QueryRowxContext(ctx,
-		"SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users WHERE id=$1",
-		id,
-	).StructScan(&model)
+	err = tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx,
+			"SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users WHERE id=$1",
+			id,
+		).StructScan(&model)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return errNotExists
+		case err != nil:
+			return fmt.Errorf("exec: %w", err)
+		}
 
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		return nil, errNotExists
-	case err != nil:
-		return nil, fmt.Errorf("exec: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &model, nil
 }
 
 // Update user form the database by her id.
-func (r *Repository) Update(ctx context.Context, user *User) error {
-	_, err := r.db.ExecContext(
+func (r *Repository) Update(ctx context.Context, user *User) (err error) {
+	defer metrics.ObserveQuery("user", "Update", time.Now(), &err)
+
+	return r.exec(
 		ctx,
 		"UPDATE users SET first_name=$1, last_name=$2, birthday=$3, updated_at=$4 WHERE id=$5",
 		user.FirstName,
@@ -79,16 +109,39 @@ func (r *Repository) Update(ctx context.Context, user *User) error {
 		user.UpdatedAt,
 		user.ID,
 	)
-	if err != nil {
-		return fmt.Errorf("exec: %w", err)
-	}
+}
+
+// UpdateLabels replace user labels in the database.
+func (r *Repository) UpdateLabels(ctx context.Context, user *User) (err error) {
+	defer metrics.ObserveQuery("user", "UpdateLabels", time.Now(), &err)
+
+	return r.exec(
+		ctx,
+		"UPDATE users SET labels=$1, updated_at=$2 WHERE id=$3",
+		user.Labels,
+		user.UpdatedAt,
+		user.ID,
+	)
+}
+
+// UpdateAvatar stores the time of the last avatar upload in the database.
+func (r *Repository) UpdateAvatar(ctx context.Context, user *User) (err error) {
+	defer metrics.ObserveQuery("user", "UpdateAvatar", time.Now(), &err)
 
-	return nil
+	return r.exec(
+		ctx,
+		"UPDATE users SET avatar_updated_at=$1, updated_at=$2 WHERE id=$3",
+		user.AvatarUpdatedAt,
+		user.UpdatedAt,
+		user.ID,
+	)
 }
 
-// Create new user in the database.
-func (r *Repository) Create(ctx context.Context, user *User) error {
-	_, err := r.db.ExecContext(
+// Create new user in the database, the tenant column is filled from the transaction setting.
+func (r *Repository) Create(ctx context.Context, user *User) (err error) {
+	defer metrics.ObserveQuery("user", "Create", time.Now(), &err)
+
+	return r.exec(
 		ctx,
 		"INSERT INTO users (id, first_name, last_name, birthday, created_at) VALUES($1, $2, $3, $4, $5)",
 		user.ID,
@@ -97,18 +150,37 @@ func (r *Repository) Create(ctx context.Context, user *User) error {
 		user.Birthday,
 		user.CreatedAt,
 	)
-	if err != nil {
-		return fmt.Errorf("exec: %w", err)
-	}
+}
+
+// Delete user from the database by her id, errNotExists is returned when the tenant has no such user.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	defer metrics.ObserveQuery("user", "Delete", time.Now(), &err)
 
-	return nil
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id=$1", id)
+		if err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		if n == 0 {
+			return errNotExists
+		}
+
+		return nil
+	})
 }
 
-// Delete user from the database by her id.
-func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
-	if _, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id=$1", id); err != nil {
-		return fmt.Errorf("exec: %w", err)
-	}
+func (r *Repository) exec(ctx context.Context, query string, args ...any) error {
+	return tenant.InTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
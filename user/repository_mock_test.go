@@ -16,8 +16,8 @@ func (m *MockRepo) Get(ctx context.Context, id uuid.UUID) (*User, error) {
 	return args.Get(0).(*User), args.Error(1)
 }
 
-func (m *MockRepo) List(ctx context.Context) ([]*User, error) {
-	args := m.Called(ctx)
+func (m *MockRepo) List(ctx context.Context, sel Selector) ([]*User, error) {
+	args := m.Called(ctx, sel)
 	return args.Get(0).([]*User), args.Error(1)
 }
 
@@ -26,6 +26,16 @@ func (m *MockRepo) Update(ctx context.Context, user *User) error {
 	return args.Error(0)
 }
 
+func (m *MockRepo) UpdateLabels(ctx context.Context, user *User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockRepo) UpdateAvatar(ctx context.Context, user *User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
 func (m *MockRepo) Create(ctx context.Context, user *User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
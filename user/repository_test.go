@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -12,6 +13,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/ihippik/template-service/tenant"
 )
 
 type repo struct {
@@ -37,7 +40,7 @@ func TestRepository_Get(t *testing.T) {
 		repo repo
 	}
 
-	columns := []string{"id", "first_name", "last_name", "birthday", "created_at", "updated_at"}
+	columns := []string{"id", "first_name", "last_name", "birthday", "labels", "created_at", "updated_at", "avatar_updated_at"}
 
 	tests := []struct {
 		name    string
@@ -52,15 +55,17 @@ func TestRepository_Get(t *testing.T) {
 					id: uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
 				},
 				repo: repo{
-					sql: prepareSQL(`SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users WHERE id=$1`),
+					sql: prepareSQL(`SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users WHERE id=$1`),
 					err: nil,
 					rows: sqlmock.NewRows(columns).AddRow(
 						"ccae37ea-d41e-4371-a3a3-89203b9e2608",
 						"Elon",
 						"Musk",
 						"1971-06-28",
+						[]byte(`{"team":"core"}`),
 						time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
 						time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+						nil,
 					),
 				},
 			},
@@ -69,6 +74,7 @@ func TestRepository_Get(t *testing.T) {
 				FirstName: "Elon",
 				LastName:  "Musk",
 				Birthday:  "1971-06-28",
+				Labels:    Labels{"team": "core"},
 				CreatedAt: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
 				UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
 			},
@@ -81,7 +87,7 @@ func TestRepository_Get(t *testing.T) {
 					id: uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
 				},
 				repo: repo{
-					sql:  prepareSQL(`SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users WHERE id=$1`),
+					sql:  prepareSQL(`SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users WHERE id=$1`),
 					err:  sql.ErrNoRows,
 					rows: sqlmock.NewRows(columns),
 				},
@@ -96,7 +102,7 @@ func TestRepository_Get(t *testing.T) {
 					id: uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
 				},
 				repo: repo{
-					sql:  prepareSQL(`SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users WHERE id=$1`),
+					sql:  prepareSQL(`SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users WHERE id=$1`),
 					err:  errors.New("some err"),
 					rows: sqlmock.NewRows(columns),
 				},
@@ -112,12 +118,14 @@ func TestRepository_Get(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
 			mock.ExpectQuery(tt.args.repo.sql).
 				WithArgs(&tt.args.p.id).
 				WillReturnRows(tt.args.repo.rows).
 				WillReturnError(tt.args.repo.err)
+			expectTxEnd(mock, tt.wantErr)
 
-			got, err := r.Get(context.Background(), tt.args.p.id)
+			got, err := r.Get(tenantCtx, tt.args.p.id)
 			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
@@ -142,11 +150,12 @@ func TestRepository_Delete(t *testing.T) {
 	}
 
 	type args struct {
-		p    param
-		repo repo
+		p        param
+		repo     repo
+		affected int64
 	}
 
-	columns := []string{"id", "first_name", "last_name", "birthday", "created_at", "updated_at"}
+	columns := []string{"id", "first_name", "last_name", "birthday", "labels", "created_at", "updated_at", "avatar_updated_at"}
 
 	tests := []struct {
 		name    string
@@ -163,9 +172,24 @@ func TestRepository_Delete(t *testing.T) {
 					sql: prepareSQL(`DELETE FROM users WHERE id=$1`),
 					err: nil,
 				},
+				affected: 1,
 			},
 			wantErr: nil,
 		},
+		{
+			name: "not found",
+			args: args{
+				p: param{
+					id: uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+				},
+				repo: repo{
+					sql: prepareSQL(`DELETE FROM users WHERE id=$1`),
+					err: nil,
+				},
+				affected: 0,
+			},
+			wantErr: errors.New("not exists"),
+		},
 		{
 			name: "some err",
 			args: args{
@@ -188,12 +212,14 @@ func TestRepository_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
 			mock.ExpectExec(tt.args.repo.sql).
 				WithArgs(&tt.args.p.id).
-				WillReturnResult(sqlmock.NewResult(0, 1)).
+				WillReturnResult(sqlmock.NewResult(0, tt.args.affected)).
 				WillReturnError(tt.args.repo.err)
+			expectTxEnd(mock, tt.wantErr)
 
-			err := r.Delete(context.Background(), tt.args.p.id)
+			err := r.Delete(tenantCtx, tt.args.p.id)
 			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
@@ -220,7 +246,7 @@ func TestRepository_Update(t *testing.T) {
 		repo repo
 	}
 
-	columns := []string{"id", "first_name", "last_name", "birthday", "created_at", "updated_at"}
+	columns := []string{"id", "first_name", "last_name", "birthday", "labels", "created_at", "updated_at", "avatar_updated_at"}
 
 	tests := []struct {
 		name    string
@@ -276,6 +302,7 @@ func TestRepository_Update(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
 			mock.ExpectExec(tt.args.repo.sql).
 				WithArgs(
 					&tt.args.p.user.FirstName,
@@ -286,8 +313,9 @@ func TestRepository_Update(t *testing.T) {
 				).
 				WillReturnResult(sqlmock.NewResult(0, 1)).
 				WillReturnError(tt.args.repo.err)
+			expectTxEnd(mock, tt.wantErr)
 
-			err := r.Update(context.Background(), tt.args.p.user)
+			err := r.Update(tenantCtx, tt.args.p.user)
 			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
@@ -314,7 +342,7 @@ func TestRepository_Created(t *testing.T) {
 		repo repo
 	}
 
-	columns := []string{"id", "first_name", "last_name", "birthday", "created_at", "updated_at"}
+	columns := []string{"id", "first_name", "last_name", "birthday", "labels", "created_at", "updated_at", "avatar_updated_at"}
 
 	tests := []struct {
 		name    string
@@ -370,6 +398,7 @@ func TestRepository_Created(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
 			mock.ExpectExec(tt.args.repo.sql).
 				WithArgs(
 					&tt.args.p.user.ID,
@@ -380,8 +409,9 @@ func TestRepository_Created(t *testing.T) {
 				).
 				WillReturnResult(sqlmock.NewResult(0, 1)).
 				WillReturnError(tt.args.repo.err)
+			expectTxEnd(mock, tt.wantErr)
 
-			err := r.Create(context.Background(), tt.args.p.user)
+			err := r.Create(tenantCtx, tt.args.p.user)
 			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
@@ -403,7 +433,7 @@ func TestRepository_List(t *testing.T) {
 		repo repo
 	}
 
-	columns := []string{"id", "first_name", "last_name", "birthday", "created_at", "updated_at"}
+	columns := []string{"id", "first_name", "last_name", "birthday", "labels", "created_at", "updated_at", "avatar_updated_at"}
 
 	tests := []struct {
 		name    string
@@ -415,15 +445,17 @@ func TestRepository_List(t *testing.T) {
 			name: "success",
 			args: args{
 				repo: repo{
-					sql: prepareSQL(`SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users`),
+					sql: prepareSQL(`SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users`),
 					err: nil,
 					rows: sqlmock.NewRows(columns).AddRow(
 						"ccae37ea-d41e-4371-a3a3-89203b9e2608",
 						"Elon",
 						"Musk",
 						"1971-06-28",
+						[]byte(`{"team":"core"}`),
 						time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
 						time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+						nil,
 					),
 				},
 			},
@@ -433,6 +465,7 @@ func TestRepository_List(t *testing.T) {
 					FirstName: "Elon",
 					LastName:  "Musk",
 					Birthday:  "1971-06-28",
+					Labels:    Labels{"team": "core"},
 					CreatedAt: time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
 					UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
 				},
@@ -443,26 +476,28 @@ func TestRepository_List(t *testing.T) {
 			name: "scan err",
 			args: args{
 				repo: repo{
-					sql: prepareSQL(`SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users`),
+					sql: prepareSQL(`SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users`),
 					err: nil,
 					rows: sqlmock.NewRows(columns).AddRow(
 						"ccae37ea-d41e-4371-a3a3-89203b9e2608",
 						"Elon",
 						"Musk",
 						"1971-06-28",
+						[]byte(`{"team":"core"}`),
 						time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
 						123,
+						nil,
 					),
 				},
 			},
 			want:    nil,
-			wantErr: errors.New("scan: sql: Scan error on column index 5, name \"updated_at\": unsupported Scan, storing driver.Value type int64 into type *time.Time"),
+			wantErr: errors.New("scan: sql: Scan error on column index 6, name \"updated_at\": unsupported Scan, storing driver.Value type int64 into type *time.Time"),
 		},
 		{
 			name: "some err",
 			args: args{
 				repo: repo{
-					sql:  prepareSQL(`SELECT id, first_name, last_name, birthday, created_at, updated_at FROM users`),
+					sql:  prepareSQL(`SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users`),
 					err:  errors.New("some err"),
 					rows: sqlmock.NewRows(columns),
 				},
@@ -478,11 +513,13 @@ func TestRepository_List(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
 			mock.ExpectQuery(tt.args.repo.sql).
 				WillReturnRows(tt.args.repo.rows).
 				WillReturnError(tt.args.repo.err)
+			expectTxEnd(mock, tt.wantErr)
 
-			got, err := r.List(context.Background())
+			got, err := r.List(tenantCtx, nil)
 			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
@@ -525,3 +562,98 @@ func TestNewRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_ListSelector(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{
+		db: sqlx.NewDb(mockDB, "sqlmock"),
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, first_name, last_name, birthday, labels, created_at, updated_at, avatar_updated_at FROM users WHERE labels @> $1::jsonb AND NOT labels ? $2`)).
+		WithArgs(`{"team":"core"}`, "legacy").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "birthday", "labels", "created_at", "updated_at", "avatar_updated_at"}))
+	mock.ExpectCommit()
+
+	got, err := r.List(tenantCtx, Selector{
+		{Key: "team", Op: OpEquals, Values: []string{"core"}},
+		{Key: "legacy", Op: OpDoesNotExist},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdateLabels(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer mockDB.Close()
+
+	r := Repository{
+		db: sqlx.NewDb(mockDB, "sqlmock"),
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "success",
+			err:     nil,
+			wantErr: nil,
+		},
+		{
+			name:    "some err",
+			err:     errors.New("some err"),
+			wantErr: errors.New("exec: some err"),
+		},
+	}
+
+	user := &User{
+		ID:        uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+		Labels:    Labels{"team": "core"},
+		UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectTenantTx(mock)
+			mock.ExpectExec(prepareSQL(`UPDATE users SET labels=$1, updated_at=$2 WHERE id=$3`)).
+				WithArgs([]byte(`{"team":"core"}`), user.UpdatedAt, user.ID).
+				WillReturnResult(sqlmock.NewResult(0, 1)).
+				WillReturnError(tt.err)
+			expectTxEnd(mock, tt.wantErr)
+
+			err := r.UpdateLabels(tenantCtx, user)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+		})
+	}
+}
+
+var tenantCtx = tenant.WithID(context.Background(), uuid.MustParse("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d"))
+
+func expectTenantTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(prepareSQL(`SELECT set_config('app.tenant_id', $1, true)`)).
+		WithArgs("5f1c3b7e-9a1d-4d4f-8a0e-3c2b1a0f9e8d").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectTxEnd(mock sqlmock.Sqlmock, err error) {
+	if err != nil {
+		mock.ExpectRollback()
+		return
+	}
+
+	mock.ExpectCommit()
+}
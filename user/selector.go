@@ -0,0 +1,165 @@
+package user
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// selector operators.
+const (
+	OpEquals       = "="
+	OpNotEquals    = "!="
+	OpIn           = "in"
+	OpNotIn        = "notin"
+	OpExists       = "exists"
+	OpDoesNotExist = "!"
+)
+
+var setRequirementRe = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// Requirement is a single label selector condition.
+type Requirement struct {
+	Key    string
+	Op     string
+	Values []string
+}
+
+// Selector is a Kubernetes-style label selector, all requirements must match.
+type Selector []Requirement
+
+// ParseSelector parses selectors like `team=core,env!=prod,tier in (db,cache),!legacy`.
+func ParseSelector(raw string) (Selector, error) {
+	var sel Selector
+
+	for _, part := range splitRequirements(raw) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid selector %q: empty requirement", raw)
+		}
+
+		req, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+
+		sel = append(sel, req)
+	}
+
+	return sel, nil
+}
+
+func splitRequirements(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	var (
+		parts []string
+		depth int
+		start int
+	)
+
+	for i, c := range raw {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, raw[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, raw[start:])
+}
+
+func parseRequirement(part string) (Requirement, error) {
+	var req Requirement
+
+	switch {
+	case strings.HasPrefix(part, "!") && !strings.Contains(part, "="):
+		req = Requirement{Key: strings.TrimSpace(part[1:]), Op: OpDoesNotExist}
+	case setRequirementRe.MatchString(part):
+		m := setRequirementRe.FindStringSubmatch(part)
+		req = Requirement{Key: m[1], Op: m[2]}
+
+		for _, v := range strings.Split(m[3], ",") {
+			req.Values = append(req.Values, strings.TrimSpace(v))
+		}
+	case strings.Contains(part, "!="):
+		key, value, _ := strings.Cut(part, "!=")
+		req = Requirement{Key: strings.TrimSpace(key), Op: OpNotEquals, Values: []string{strings.TrimSpace(value)}}
+	case strings.Contains(part, "="):
+		key, value, _ := strings.Cut(part, "=")
+		value = strings.TrimPrefix(value, "=")
+		req = Requirement{Key: strings.TrimSpace(key), Op: OpEquals, Values: []string{strings.TrimSpace(value)}}
+	default:
+		req = Requirement{Key: part, Op: OpExists}
+	}
+
+	if err := validateLabelKey(req.Key); err != nil {
+		return Requirement{}, err
+	}
+
+	for _, v := range req.Values {
+		if err := validateLabelValue(v); err != nil {
+			return Requirement{}, err
+		}
+	}
+
+	return req, nil
+}
+
+// where renders the selector as an SQL condition over the indexed labels column,
+// the placeholders are numbered starting from the first argument number.
+func (s Selector) where(first int) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	placeholder := func(arg any) string {
+		args = append(args, arg)
+		return "$" + strconv.Itoa(first+len(args)-1)
+	}
+
+	contains := func(key, value string) string {
+		data, _ := json.Marshal(map[string]string{key: value})
+		return "labels @> " + placeholder(string(data)) + "::jsonb"
+	}
+
+	// every requirement gets its own condition, so repeated keys must all match.
+	for _, req := range s {
+		switch req.Op {
+		case OpEquals:
+			conds = append(conds, contains(req.Key, req.Values[0]))
+		case OpNotEquals:
+			conds = append(conds, "NOT "+contains(req.Key, req.Values[0]))
+		case OpIn, OpNotIn:
+			alts := make([]string, 0, len(req.Values))
+			for _, v := range req.Values {
+				alts = append(alts, contains(req.Key, v))
+			}
+
+			cond := "(" + strings.Join(alts, " OR ") + ")"
+			if req.Op == OpNotIn {
+				cond = "NOT " + cond
+			}
+
+			conds = append(conds, cond)
+		case OpExists:
+			conds = append(conds, "labels ? "+placeholder(req.Key))
+		case OpDoesNotExist:
+			conds = append(conds, "NOT labels ? "+placeholder(req.Key))
+		}
+	}
+
+	return strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,131 @@
+package user
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Selector
+		wantErr error
+	}{
+		{
+			name:    "empty",
+			raw:     "",
+			want:    nil,
+			wantErr: nil,
+		},
+		{
+			name: "equality based",
+			raw:  "team=core, env!=prod,tier==db",
+			want: Selector{
+				{Key: "team", Op: OpEquals, Values: []string{"core"}},
+				{Key: "env", Op: OpNotEquals, Values: []string{"prod"}},
+				{Key: "tier", Op: OpEquals, Values: []string{"db"}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "set based",
+			raw:  "tier in (db, cache),example.com/env notin (prod),beta,!legacy",
+			want: Selector{
+				{Key: "tier", Op: OpIn, Values: []string{"db", "cache"}},
+				{Key: "example.com/env", Op: OpNotIn, Values: []string{"prod"}},
+				{Key: "beta", Op: OpExists},
+				{Key: "legacy", Op: OpDoesNotExist},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "empty requirement",
+			raw:     "team=core,,env=prod",
+			want:    nil,
+			wantErr: errors.New(`invalid selector "team=core,,env=prod": empty requirement`),
+		},
+		{
+			name:    "invalid key",
+			raw:     "-team=core",
+			want:    nil,
+			wantErr: errors.New(`invalid label key "-team": name must be 1-63 alphanumeric characters, '-', '_' or '.'`),
+		},
+		{
+			name:    "invalid value",
+			raw:     "team=core team",
+			want:    nil,
+			wantErr: errors.New(`invalid label value "core team": must be empty or 1-63 alphanumeric characters, '-', '_' or '.'`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelector(tt.raw)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSelector_where(t *testing.T) {
+	tests := []struct {
+		name     string
+		sel      Selector
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "empty",
+			sel:      nil,
+			wantSQL:  "",
+			wantArgs: nil,
+		},
+		{
+			name: "all operators",
+			sel: Selector{
+				{Key: "team", Op: OpEquals, Values: []string{"core"}},
+				{Key: "env", Op: OpNotEquals, Values: []string{"prod"}},
+				{Key: "tier", Op: OpIn, Values: []string{"db", "cache"}},
+				{Key: "zone", Op: OpNotIn, Values: []string{"eu"}},
+				{Key: "beta", Op: OpExists},
+				{Key: "legacy", Op: OpDoesNotExist},
+			},
+			wantSQL: "labels @> $2::jsonb AND NOT labels @> $3::jsonb AND (labels @> $4::jsonb OR labels @> $5::jsonb) " +
+				"AND NOT (labels @> $6::jsonb) AND labels ? $7 AND NOT labels ? $8",
+			wantArgs: []any{
+				`{"team":"core"}`,
+				`{"env":"prod"}`,
+				`{"tier":"db"}`,
+				`{"tier":"cache"}`,
+				`{"zone":"eu"}`,
+				"beta",
+				"legacy",
+			},
+		},
+		{
+			name: "repeated equality",
+			sel: Selector{
+				{Key: "team", Op: OpEquals, Values: []string{"core"}},
+				{Key: "team", Op: OpEquals, Values: []string{"infra"}},
+			},
+			wantSQL:  "labels @> $2::jsonb AND labels @> $3::jsonb",
+			wantArgs: []any{`{"team":"core"}`, `{"team":"infra"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs := tt.sel.where(2)
+			assert.Equal(t, tt.wantSQL, gotSQL)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+		})
+	}
+}
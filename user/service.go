@@ -1,83 +1,141 @@
 package user
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"github.com/ihippik/template-service/blob"
 	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/logging"
+	"github.com/ihippik/template-service/policy"
+	"github.com/ihippik/template-service/tenant"
+	"github.com/ihippik/template-service/tracing"
 )
 
 type repository interface {
 	Get(ctx context.Context, id uuid.UUID) (*User, error)
-	List(ctx context.Context) ([]*User, error)
+	List(ctx context.Context, sel Selector) ([]*User, error)
 	Update(ctx context.Context, user *User) error
+	UpdateLabels(ctx context.Context, user *User) error
+	UpdateAvatar(ctx context.Context, user *User) error
 	Create(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// actions authorized by the policy.
+const (
+	ActionList         = "users:list"
+	ActionGet          = "users:get"
+	ActionCreate       = "users:create"
+	ActionUpdate       = "users:update"
+	ActionUpdateLabels = "users:update_labels"
+	ActionUploadAvatar = "users:upload_avatar"
+	ActionGetAvatar    = "users:get_avatar"
+	ActionDelete       = "users:delete"
+)
+
 // Service represent the main application structure.
 type Service struct {
-	cfg    *config.Config
-	logger *zap.Logger
-	repo   repository
+	cfg     *config.Config
+	logger  *zap.Logger
+	repo    repository
+	storage blob.Storage
+	authz   policy.Authorizer
 }
 
 var timeNow = time.Now
 
-// NewService creates new Service entity.
-func NewService(cfg *config.Config, logger *zap.Logger, repo repository) *Service {
-	return &Service{cfg: cfg, logger: logger, repo: repo}
+// NewService creates new Service entity, authz may be nil when no policy is configured.
+func NewService(
+	cfg *config.Config,
+	logger *zap.Logger,
+	repo repository,
+	storage blob.Storage,
+	authz policy.Authorizer,
+) *Service {
+	return &Service{cfg: cfg, logger: logger, repo: repo, storage: storage, authz: authz}
 }
 
 // GetUser get user entity by her identification.
-func (svc *Service) GetUser(ctx context.Context, id uuid.UUID) (*User, error) {
+func (svc *Service) GetUser(ctx context.Context, id uuid.UUID) (_ *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.GetUser")
+	defer tracing.End(span, &err)
+
+	model, err := svc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.authorize(ctx, ActionGet, model, nil); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func (svc *Service) getUser(ctx context.Context, id uuid.UUID) (*User, error) {
 	model, err := svc.repo.Get(ctx, id)
 	if err == nil {
+		model.AvatarURLs = avatarURLs(model)
 		return model, nil
 	}
 
 	if errors.Is(err, errNotExists) {
-		svc.logger.Warn("user not found", zap.String("id", id.String()))
+		svc.log(ctx).Warn("user not found", zap.String("id", id.String()))
 		return nil, newNotFoundErr(NotFound, "user not found")
 	}
 
-	svc.logger.Error("could not get user", zap.Error(err))
+	svc.log(ctx).Error("could not get user", zap.Error(err))
 
 	return nil, fmt.Errorf("could not get user: %w", err)
 }
 
-// ListUser fetch all users.
-func (svc *Service) ListUser(ctx context.Context) ([]*User, error) {
-	models, err := svc.repo.List(ctx)
+// ListUser fetch all users matching the label selector.
+func (svc *Service) ListUser(ctx context.Context, sel Selector) (_ []*User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.ListUser")
+	defer tracing.End(span, &err)
+
+	if err := svc.authorize(ctx, ActionList, nil, nil); err != nil {
+		return nil, err
+	}
+
+	models, err := svc.repo.List(ctx, sel)
 	if err != nil {
-		svc.logger.Error("could not fetch users", zap.Error(err))
+		svc.log(ctx).Error("could not fetch users", zap.Error(err))
 		return nil, fmt.Errorf("list: %w", err)
 	}
 
+	for _, model := range models {
+		model.AvatarURLs = avatarURLs(model)
+	}
+
 	return models, nil
 }
 
 // UpdateUser update user entity by her identification.
-func (svc *Service) UpdateUser(ctx context.Context, id uuid.UUID, dto DTO) (*User, error) {
+func (svc *Service) UpdateUser(ctx context.Context, id uuid.UUID, dto DTO) (_ *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.UpdateUser")
+	defer tracing.End(span, &err)
+
 	if err := dto.Validate(); err != nil {
-		svc.logger.Warn("dto validation error", zap.Error(err))
-		return nil, newValidationErr(ValidationError, err.Error())
+		svc.log(ctx).Warn("dto validation error", zap.Error(err))
+		return nil, newFieldValidationErr(ValidationError, err)
 	}
 
-	model, err := svc.repo.Get(ctx, id)
+	model, err := svc.getUser(ctx, id)
 	if err != nil {
-		svc.logger.Error("could not get user", zap.Error(err))
-		return nil, fmt.Errorf("could not get user: %w", err)
+		return nil, err
 	}
 
-	if model == nil {
-		svc.logger.Warn("user not found", zap.String("id", id.String()))
-		return nil, newNotFoundErr(NotFound, "user not found")
+	if err := svc.authorize(ctx, ActionUpdate, model, dto); err != nil {
+		return nil, err
 	}
 
 	now := timeNow().UTC()
@@ -87,18 +145,158 @@ func (svc *Service) UpdateUser(ctx context.Context, id uuid.UUID, dto DTO) (*Use
 	model.LastName = dto.LastName
 
 	if err := svc.repo.Update(ctx, model); err != nil {
-		svc.logger.Error("update user error", zap.Error(err))
+		svc.log(ctx).Error("update user error", zap.Error(err))
 		return nil, fmt.Errorf("update user: %w", err)
 	}
 
 	return model, nil
 }
 
+// UpdateLabels replace user labels.
+func (svc *Service) UpdateLabels(ctx context.Context, id uuid.UUID, labels Labels) (_ *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.UpdateLabels")
+	defer tracing.End(span, &err)
+
+	if err := labels.Validate(); err != nil {
+		svc.log(ctx).Warn("labels validation error", zap.Error(err))
+		return nil, newFieldValidationErr(ValidationError, err)
+	}
+
+	model, err := svc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.authorize(ctx, ActionUpdateLabels, model, labels); err != nil {
+		return nil, err
+	}
+
+	now := timeNow().UTC()
+
+	model.UpdatedAt = &now
+	model.Labels = labels
+
+	if err := svc.repo.UpdateLabels(ctx, model); err != nil {
+		svc.log(ctx).Error("update user labels error", zap.Error(err))
+		return nil, fmt.Errorf("update labels: %w", err)
+	}
+
+	return model, nil
+}
+
+// UploadAvatar validates the image and stores its square thumbnails of every avatar size.
+func (svc *Service) UploadAvatar(ctx context.Context, id uuid.UUID, r io.Reader) (_ *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.UploadAvatar")
+	defer tracing.End(span, &err)
+
+	model, err := svc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.authorize(ctx, ActionUploadAvatar, model, nil); err != nil {
+		return nil, err
+	}
+
+	maxSize := svc.cfg.Avatar.MaxSize
+
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		svc.log(ctx).Warn("could not read avatar", zap.Error(err))
+		return nil, newBadRequest(InvalidAvatar, err.Error())
+	}
+
+	if int64(len(data)) > maxSize {
+		svc.log(ctx).Warn("avatar too large", zap.String("id", id.String()))
+		return nil, newTooLargeErr(AvatarTooLarge, fmt.Sprintf("avatar exceeds %d bytes", maxSize))
+	}
+
+	thumbs, err := makeThumbnails(data)
+	if err != nil {
+		svc.log(ctx).Warn("could not process avatar", zap.Error(err))
+
+		if errors.Is(err, errUnsupportedAvatar) {
+			return nil, newUnsupportedMediaErr(UnsupportedAvatar, err.Error())
+		}
+
+		return nil, newValidationErr(InvalidAvatar, err.Error())
+	}
+
+	tenantID, _ := tenant.FromContext(ctx)
+
+	for size, thumb := range thumbs {
+		if err := svc.storage.Put(ctx, avatarKey(tenantID, id, size), bytes.NewReader(thumb)); err != nil {
+			svc.log(ctx).Error("could not store avatar", zap.Error(err))
+			return nil, fmt.Errorf("put avatar: %w", err)
+		}
+	}
+
+	// the column keeps microseconds, the stored time must give the same avatar version as the returned one.
+	now := timeNow().UTC().Truncate(time.Microsecond)
+
+	model.UpdatedAt = &now
+	model.AvatarUpdatedAt = &now
+	model.AvatarURLs = avatarURLs(model)
+
+	if err := svc.repo.UpdateAvatar(ctx, model); err != nil {
+		svc.log(ctx).Error("update user avatar error", zap.Error(err))
+		return nil, fmt.Errorf("update avatar: %w", err)
+	}
+
+	return model, nil
+}
+
+// GetAvatar open user avatar thumbnail of the specified size, the caller must close it.
+func (svc *Service) GetAvatar(ctx context.Context, id uuid.UUID, size int) (_ *blob.Object, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.GetAvatar")
+	defer tracing.End(span, &err)
+
+	if !isAvatarSize(size) {
+		return nil, newBadRequest(InvalidAvatarSize, fmt.Sprintf("size must be one of %v", AvatarSizes))
+	}
+
+	model, err := svc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.authorize(ctx, ActionGetAvatar, model, nil); err != nil {
+		return nil, err
+	}
+
+	if model.AvatarUpdatedAt == nil {
+		return nil, newNotFoundErr(NotFound, "avatar not found")
+	}
+
+	tenantID, _ := tenant.FromContext(ctx)
+
+	obj, err := svc.storage.Get(ctx, avatarKey(tenantID, id, size))
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			svc.log(ctx).Warn("avatar not found in storage", zap.String("id", id.String()))
+			return nil, newNotFoundErr(NotFound, "avatar not found")
+		}
+
+		svc.log(ctx).Error("could not get avatar", zap.Error(err))
+
+		return nil, fmt.Errorf("get avatar: %w", err)
+	}
+
+	return obj, nil
+}
+
 // CreateUser create new entity user.
-func (svc *Service) CreateUser(ctx context.Context, dto DTO) (*User, error) {
+func (svc *Service) CreateUser(ctx context.Context, dto DTO) (_ *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.CreateUser")
+	defer tracing.End(span, &err)
+
 	if err := dto.Validate(); err != nil {
-		svc.logger.Warn("dto validation error", zap.Error(err))
-		return nil, newValidationErr(ValidationError, err.Error())
+		svc.log(ctx).Warn("dto validation error", zap.Error(err))
+		return nil, newFieldValidationErr(ValidationError, err)
+	}
+
+	if err := svc.authorize(ctx, ActionCreate, nil, dto); err != nil {
+		return nil, err
 	}
 
 	model := User{
@@ -110,7 +308,7 @@ func (svc *Service) CreateUser(ctx context.Context, dto DTO) (*User, error) {
 	}
 
 	if err := svc.repo.Create(ctx, &model); err != nil {
-		svc.logger.Error("could not create user", zap.Error(err))
+		svc.log(ctx).Error("could not create user", zap.Error(err))
 		return nil, fmt.Errorf("could not create user: %w", err)
 	}
 
@@ -118,11 +316,69 @@ func (svc *Service) CreateUser(ctx context.Context, dto DTO) (*User, error) {
 }
 
 // DeleteUser delete a user by her identification.
-func (svc *Service) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	if err := svc.repo.Delete(ctx, id); err != nil {
-		svc.logger.Error("could not delete user", zap.Error(err))
+func (svc *Service) DeleteUser(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.DeleteUser")
+	defer tracing.End(span, &err)
+
+	// the policy needs the target user.
+	if svc.authz != nil {
+		model, err := svc.getUser(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.authorize(ctx, ActionDelete, model, nil); err != nil {
+			return err
+		}
+	}
+
+	// the user of another tenant isn't deleted under row-level security and is reported as missing,
+	// so are its avatars.
+	err = svc.repo.Delete(ctx, id)
+
+	switch {
+	case errors.Is(err, errNotExists):
+		svc.log(ctx).Warn("user not found", zap.String("id", id.String()))
+		return newNotFoundErr(NotFound, "user not found")
+	case err != nil:
+		svc.log(ctx).Error("could not delete user", zap.Error(err))
 		return fmt.Errorf("delete user: %w", err)
 	}
 
+	tenantID, _ := tenant.FromContext(ctx)
+
+	// the user is gone already, a thumbnail left behind is only logged.
+	for _, size := range AvatarSizes {
+		if err := svc.storage.Delete(ctx, avatarKey(tenantID, id, size)); err != nil {
+			svc.log(ctx).Error("could not delete avatar", zap.String("id", id.String()), zap.Error(err))
+		}
+	}
+
 	return nil
 }
+
+// authorize consults the policy before the operation on the target user.
+func (svc *Service) authorize(ctx context.Context, action string, target *User, input any) error {
+	if svc.authz == nil {
+		return nil
+	}
+
+	err := svc.authz.Authorize(ctx, action, target, input)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, policy.ErrDenied) {
+		svc.log(ctx).Warn("operation denied by policy", zap.String("action", action), zap.Error(err))
+		return newForbiddenErr(Forbidden, "operation is not allowed")
+	}
+
+	svc.log(ctx).Error("could not authorize operation", zap.String("action", action), zap.Error(err))
+
+	return fmt.Errorf("authorize: %w", err)
+}
+
+// log returns the request-scoped logger of the context.
+func (svc *Service) log(ctx context.Context) *zap.Logger {
+	return logging.FromContext(ctx, svc.logger)
+}
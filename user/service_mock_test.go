@@ -2,9 +2,12 @@ package user
 
 import (
 	"context"
+	"io"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/ihippik/template-service/blob"
 )
 
 type MockServer struct {
@@ -16,11 +19,16 @@ func (m *MockServer) GetUser(ctx context.Context, id uuid.UUID) (*User, error) {
 	return args.Get(0).(*User), args.Error(1)
 }
 
-func (m *MockServer) ListUser(ctx context.Context) ([]*User, error) {
-	args := m.Called(ctx)
+func (m *MockServer) ListUser(ctx context.Context, sel Selector) ([]*User, error) {
+	args := m.Called(ctx, sel)
 	return args.Get(0).([]*User), args.Error(1)
 }
 
+func (m *MockServer) UpdateLabels(ctx context.Context, id uuid.UUID, labels Labels) (*User, error) {
+	args := m.Called(ctx, id, labels)
+	return args.Get(0).(*User), args.Error(1)
+}
+
 func (m *MockServer) UpdateUser(ctx context.Context, id uuid.UUID, dto DTO) (*User, error) {
 	args := m.Called(ctx, id, dto)
 	return args.Get(0).(*User), args.Error(1)
@@ -35,3 +43,13 @@ func (m *MockServer) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+
+func (m *MockServer) UploadAvatar(ctx context.Context, id uuid.UUID, r io.Reader) (*User, error) {
+	args := m.Called(ctx, id, r)
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockServer) GetAvatar(ctx context.Context, id uuid.UUID, size int) (*blob.Object, error) {
+	args := m.Called(ctx, id, size)
+	return args.Get(0).(*blob.Object), args.Error(1)
+}
@@ -12,7 +12,10 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 
+	"github.com/ihippik/template-service/blob"
 	"github.com/ihippik/template-service/config"
+	"github.com/ihippik/template-service/policy"
+	"github.com/ihippik/template-service/tenant"
 )
 
 func TestService_GetUser(t *testing.T) {
@@ -157,9 +160,28 @@ func TestService_DeleteUser(t *testing.T) {
 			},
 			wantErr: errors.New("delete user: some error"),
 		},
+		{
+			name: "not found",
+			setup: func() {
+				setDelete(
+					uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+					errNotExists,
+				)
+			},
+			args: args{
+				id: uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
+			},
+			wantErr: newNotFoundErr(NotFound, "user not found"),
+		},
 	}
 
-	svc := &Service{logger: zap.NewNop(), repo: repo}
+	tenantID, _ := tenant.FromContext(tenantCtx)
+	otherTenantID := uuid.MustParse("0d6a2c4e-1b3f-4e5a-9c7d-8f0e1a2b3c4d")
+
+	storage, err := blob.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	svc := &Service{logger: zap.NewNop(), repo: repo, storage: storage}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -167,12 +189,27 @@ func TestService_DeleteUser(t *testing.T) {
 
 			tt.setup()
 
-			err := svc.DeleteUser(context.Background(), tt.args.id)
+			for _, size := range AvatarSizes {
+				for _, id := range []uuid.UUID{tenantID, otherTenantID} {
+					assert.NoError(t, storage.Put(context.Background(), avatarKey(id, tt.args.id, size), bytes.NewReader([]byte("avatar"))))
+				}
+			}
+
+			err := svc.DeleteUser(tenantCtx, tt.args.id)
 			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
 				assert.NoError(t, tt.wantErr)
 			}
+
+			// the avatar is removed only with the user, the one of the same id in another tenant stays.
+			for _, size := range AvatarSizes {
+				_, err := storage.Get(context.Background(), avatarKey(tenantID, tt.args.id, size))
+				assert.Equal(t, tt.wantErr == nil, errors.Is(err, blob.ErrNotFound))
+
+				_, err = storage.Get(context.Background(), avatarKey(otherTenantID, tt.args.id, size))
+				assert.NoError(t, err)
+			}
 		})
 	}
 }
@@ -240,7 +277,7 @@ func TestService_CreateUser(t *testing.T) {
 			}},
 			setup:   func() {},
 			want:    nil,
-			wantErr: errors.New("Key: 'DTO.Birthday' Error:Field validation for 'Birthday' failed on the 'required' tag"),
+			wantErr: errors.New("birthday is required"),
 		},
 		{
 			name: "some error",
@@ -377,7 +414,7 @@ func TestService_UpdateUser(t *testing.T) {
 				setGet(
 					uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608"),
 					nil,
-					nil,
+					errNotExists,
 				)
 			},
 			want:    nil,
@@ -391,7 +428,7 @@ func TestService_UpdateUser(t *testing.T) {
 			}},
 			setup:   func() {},
 			want:    nil,
-			wantErr: errors.New("Key: 'DTO.Birthday' Error:Field validation for 'Birthday' failed on the 'required' tag"),
+			wantErr: errors.New("birthday is required"),
 		},
 		{
 			name: "get: some error",
@@ -485,7 +522,9 @@ func TestService_ListUser(t *testing.T) {
 	repo := new(MockRepo)
 
 	setList := func(users []*User, err error) {
-		repo.On("List", mock.Anything).Return(users, err).Once()
+		repo.On("List", mock.Anything, Selector{{Key: "team", Op: OpEquals, Values: []string{"core"}}}).
+			Return(users, err).
+			Once()
 	}
 
 	tests := []struct {
@@ -549,7 +588,10 @@ func TestService_ListUser(t *testing.T) {
 
 			tt.setup()
 
-			got, err := svc.ListUser(context.Background())
+			got, err := svc.ListUser(
+				context.Background(),
+				Selector{{Key: "team", Op: OpEquals, Values: []string{"core"}}},
+			)
 			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
@@ -567,12 +609,14 @@ func toPointer[T any](d T) *T {
 
 func TestNewService(t *testing.T) {
 	type args struct {
-		cfg    *config.Config
-		logger *zap.Logger
-		repo   repository
+		cfg     *config.Config
+		logger  *zap.Logger
+		repo    repository
+		storage blob.Storage
 	}
 
 	repo := new(MockRepo)
+	storage := &blob.Local{}
 
 	tests := []struct {
 		name string
@@ -595,8 +639,9 @@ func TestNewService(t *testing.T) {
 						StackTrace: false,
 					},
 				},
-				logger: &zap.Logger{},
-				repo:   repo,
+				logger:  &zap.Logger{},
+				repo:    repo,
+				storage: storage,
 			},
 			want: &Service{
 				cfg: &config.Config{
@@ -612,15 +657,414 @@ func TestNewService(t *testing.T) {
 						StackTrace: false,
 					},
 				},
-				logger: &zap.Logger{},
-				repo:   repo,
+				logger:  &zap.Logger{},
+				repo:    repo,
+				storage: storage,
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, NewService(tt.args.cfg, tt.args.logger, tt.args.repo), "NewService(%v, %v, %v)", tt.args.cfg, tt.args.logger, tt.args.repo)
+			assert.Equalf(
+				t,
+				tt.want,
+				NewService(tt.args.cfg, tt.args.logger, tt.args.repo, tt.args.storage, nil),
+				"NewService(%v, %v, %v, %v, nil)", tt.args.cfg, tt.args.logger, tt.args.repo, tt.args.storage,
+			)
+		})
+	}
+}
+
+func TestService_UpdateLabels(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	id := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+
+	repo := new(MockRepo)
+
+	setGet := func(user *User, err error) {
+		repo.On("Get", mock.Anything, id).Return(user, err).Once()
+	}
+
+	setUpdateLabels := func(user *User, err error) {
+		repo.On("UpdateLabels", mock.Anything, user).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		labels  Labels
+		setup   func()
+		want    *User
+		wantErr error
+	}{
+		{
+			name:   "success",
+			labels: Labels{"team": "core"},
+			setup: func() {
+				setGet(&User{ID: id, FirstName: "Elon", Labels: Labels{"team": "mars"}}, nil)
+				setUpdateLabels(
+					&User{
+						ID:        id,
+						FirstName: "Elon",
+						Labels:    Labels{"team": "core"},
+						UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					nil,
+				)
+			},
+			want: &User{
+				ID:        id,
+				FirstName: "Elon",
+				Labels:    Labels{"team": "core"},
+				UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "validation error",
+			labels:  Labels{"team": "core team"},
+			setup:   func() {},
+			want:    nil,
+			wantErr: errors.New(`team must be empty or up to 63 alphanumeric characters, '-', '_' or '.'`),
+		},
+		{
+			name:   "not found",
+			labels: Labels{"team": "core"},
+			setup: func() {
+				setGet(nil, errNotExists)
+			},
+			want:    nil,
+			wantErr: newNotFoundErr(NotFound, "user not found"),
+		},
+		{
+			name:   "some error",
+			labels: Labels{"team": "core"},
+			setup: func() {
+				setGet(&User{ID: id}, nil)
+				setUpdateLabels(
+					&User{
+						ID:        id,
+						Labels:    Labels{"team": "core"},
+						UpdatedAt: toPointer(time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					errors.New("some error"),
+				)
+			},
+			want:    nil,
+			wantErr: errors.New("update labels: some error"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.UpdateLabels(context.Background(), id, tt.labels)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_UploadAvatar(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	id := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := new(MockRepo)
+
+	setGet := func(user *User, err error) {
+		repo.On("Get", mock.Anything, id).Return(user, err).Once()
+	}
+
+	setUpdateAvatar := func(user *User, err error) {
+		repo.On("UpdateAvatar", mock.Anything, user).Return(err).Once()
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		setup   func()
+		want    *User
+		wantErr error
+	}{
+		{
+			name: "success",
+			data: testImage(t, 300, 200),
+			setup: func() {
+				setGet(&User{ID: id}, nil)
+				setUpdateAvatar(
+					&User{
+						ID:              id,
+						UpdatedAt:       &now,
+						AvatarUpdatedAt: &now,
+						AvatarURLs:      avatarURLs(&User{ID: id, AvatarUpdatedAt: &now}),
+					},
+					nil,
+				)
+			},
+			want: &User{
+				ID:              id,
+				UpdatedAt:       &now,
+				AvatarUpdatedAt: &now,
+				AvatarURLs:      avatarURLs(&User{ID: id, AvatarUpdatedAt: &now}),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "too large",
+			data: make([]byte, 1025),
+			setup: func() {
+				setGet(&User{ID: id}, nil)
+			},
+			want:    nil,
+			wantErr: newTooLargeErr(AvatarTooLarge, "avatar exceeds 1024 bytes"),
+		},
+		{
+			name: "unsupported type",
+			data: []byte("%PDF-1.4"),
+			setup: func() {
+				setGet(&User{ID: id}, nil)
+			},
+			want:    nil,
+			wantErr: newUnsupportedMediaErr(UnsupportedAvatar, "unsupported image type: application/pdf"),
+		},
+		{
+			name: "user not found",
+			data: testImage(t, 10, 10),
+			setup: func() {
+				setGet(nil, errNotExists)
+			},
+			want:    nil,
+			wantErr: newNotFoundErr(NotFound, "user not found"),
+		},
+	}
+
+	storage, err := blob.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	svc := &Service{
+		cfg:     &config.Config{Avatar: config.AvatarCfg{MaxSize: 1024}},
+		logger:  zap.NewNop(),
+		repo:    repo,
+		storage: storage,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.UploadAvatar(context.Background(), id, bytes.NewReader(tt.data))
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.Equal(t, tt.wantErr, err)
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_GetAvatar(t *testing.T) {
+	id := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := new(MockRepo)
+
+	setGet := func(user *User, err error) {
+		repo.On("Get", mock.Anything, id).Return(user, err).Once()
+	}
+
+	storage, err := blob.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Put(context.Background(), avatarKey(uuid.Nil, id, 64), bytes.NewReader([]byte("avatar"))))
+
+	tests := []struct {
+		name     string
+		size     int
+		setup    func()
+		wantSize int64
+		wantErr  error
+	}{
+		{
+			name: "success",
+			size: 64,
+			setup: func() {
+				setGet(&User{ID: id, AvatarUpdatedAt: &now}, nil)
+			},
+			wantSize: 6,
+			wantErr:  nil,
+		},
+		{
+			name:    "invalid size",
+			size:    65,
+			setup:   func() {},
+			wantErr: newBadRequest(InvalidAvatarSize, "size must be one of [64 128 256]"),
+		},
+		{
+			name: "no avatar",
+			size: 64,
+			setup: func() {
+				setGet(&User{ID: id}, nil)
+			},
+			wantErr: newNotFoundErr(NotFound, "avatar not found"),
+		},
+		{
+			name: "missing in storage",
+			size: 128,
+			setup: func() {
+				setGet(&User{ID: id, AvatarUpdatedAt: &now}, nil)
+			},
+			wantErr: newNotFoundErr(NotFound, "avatar not found"),
+		},
+	}
+
+	svc := &Service{logger: zap.NewNop(), repo: repo, storage: storage}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+
+			tt.setup()
+
+			got, err := svc.GetAvatar(context.Background(), id, tt.size)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			assert.NoError(t, tt.wantErr)
+			assert.Equal(t, tt.wantSize, got.Size)
+			assert.NoError(t, got.Close())
+		})
+	}
+}
+
+type MockAuthorizer struct {
+	mock.Mock
+}
+
+func (m *MockAuthorizer) Authorize(ctx context.Context, action string, resource, input any) error {
+	args := m.Called(ctx, action, resource, input)
+	return args.Error(0)
+}
+
+func TestService_authorize(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	id := uuid.MustParse("ccae37ea-d41e-4371-a3a3-89203b9e2608")
+	user := &User{ID: id, FirstName: "Elon", LastName: "Musk", Birthday: "1971-06-28"}
+
+	repo := new(MockRepo)
+	authz := new(MockAuthorizer)
+
+	tests := []struct {
+		name    string
+		setup   func()
+		call    func(svc *Service) error
+		wantErr error
+	}{
+		{
+			name: "update allowed",
+			setup: func() {
+				repo.On("Get", mock.Anything, id).Return(&User{ID: id, Birthday: "1971-06-28"}, nil).Once()
+				authz.On("Authorize", mock.Anything, ActionUpdate, mock.Anything, DTO{FirstName: "Elon", LastName: "Musk", Birthday: "1971-06-28"}).
+					Return(nil).Once()
+				repo.On("Update", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			call: func(svc *Service) error {
+				_, err := svc.UpdateUser(context.Background(), id, DTO{FirstName: "Elon", LastName: "Musk", Birthday: "1971-06-28"})
+				return err
+			},
+			wantErr: nil,
+		},
+		{
+			name: "update denied",
+			setup: func() {
+				repo.On("Get", mock.Anything, id).Return(&User{ID: id, Birthday: "1971-06-28"}, nil).Once()
+				authz.On("Authorize", mock.Anything, ActionUpdate, mock.Anything, mock.Anything).
+					Return(policy.ErrDenied).Once()
+			},
+			call: func(svc *Service) error {
+				_, err := svc.UpdateUser(context.Background(), id, DTO{FirstName: "Elon", LastName: "Musk", Birthday: "1971-06-28"})
+				return err
+			},
+			wantErr: newForbiddenErr(Forbidden, "operation is not allowed"),
+		},
+		{
+			name: "list error",
+			setup: func() {
+				authz.On("Authorize", mock.Anything, ActionList, (*User)(nil), nil).
+					Return(errors.New("some error")).Once()
+			},
+			call: func(svc *Service) error {
+				_, err := svc.ListUser(context.Background(), nil)
+				return err
+			},
+			wantErr: errors.New("authorize: some error"),
+		},
+		{
+			name: "delete checks the target user",
+			setup: func() {
+				repo.On("Get", mock.Anything, id).Return(user, nil).Once()
+				authz.On("Authorize", mock.Anything, ActionDelete, user, nil).Return(nil).Once()
+				repo.On("Delete", mock.Anything, id).Return(nil).Once()
+			},
+			call: func(svc *Service) error {
+				return svc.DeleteUser(context.Background(), id)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "delete missing user",
+			setup: func() {
+				repo.On("Get", mock.Anything, id).Return((*User)(nil), errNotExists).Once()
+			},
+			call: func(svc *Service) error {
+				return svc.DeleteUser(context.Background(), id)
+			},
+			wantErr: newNotFoundErr(NotFound, "user not found"),
+		},
+	}
+
+	storage, err := blob.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	svc := &Service{logger: zap.NewNop(), repo: repo, storage: storage, authz: authz}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer repo.AssertExpectations(t)
+			defer authz.AssertExpectations(t)
+
+			tt.setup()
+
+			err := tt.call(svc)
+			if err != nil && assert.Error(t, tt.wantErr, err.Error()) {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, tt.wantErr)
+			}
 		})
 	}
 }
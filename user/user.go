@@ -3,8 +3,9 @@ package user
 import (
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+
+	"github.com/ihippik/template-service/apperr"
 )
 
 // User server domain struct.
@@ -13,8 +14,12 @@ type User struct {
 	FirstName string     `db:"first_name" json:"firstName"`
 	LastName  string     `db:"last_name" json:"lastName"`
 	Birthday  string     `json:"birthday"`
+	Labels    Labels     `json:"labels,omitempty"`
 	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt *time.Time `db:"updated_at" json:"updatedAt"`
+
+	AvatarURLs      map[string]string `db:"-" json:"avatarUrls,omitempty"`
+	AvatarUpdatedAt *time.Time        `db:"avatar_updated_at" json:"-"`
 }
 
 // DTO represent data transfer object for creating and updating a new entity.
@@ -26,7 +31,5 @@ type DTO struct {
 
 // Validate check mandatory fields.
 func (d DTO) Validate() error {
-	validate := validator.New()
-
-	return validate.Struct(d)
+	return apperr.ValidateStruct(d)
 }